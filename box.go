@@ -0,0 +1,272 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+var (
+	// boxOutdated matches the message printed by `vagrant box outdated` when the box of a machine has a newer version.
+	boxOutdated = regexp.MustCompile(`A newer version of the box '.+' for provider '.+' is\s+available!`)
+	// globalBoxOutdated matches an outdated box listed by `vagrant box outdated --global`.
+	globalBoxOutdated = regexp.MustCompile(`^\* '(.+)' for '(.+)' is outdated! Current: (\S+)\. Latest: (\S+?)\.?$`)
+	// globalBoxNoMetadata matches a box listed by `vagrant box outdated --global` that has no version information.
+	globalBoxNoMetadata = regexp.MustCompile(`^\* '(.+)' for '(.+)' wasn't added from a catalog`)
+	// boxExists matches the error printed by `vagrant box add` when the box is already installed.
+	boxExists = regexp.MustCompile(`(?i)the box you're attempting to add already exists`)
+	// boxExistsField matches the Name, Provider and Version lines that follow the box exists error.
+	boxExistsField = regexp.MustCompile(`(?m)^\s*(Name|Provider|Version):\s*(\S+)`)
+)
+
+// Box encapsulates Vagrant box metadata.
+type Box struct {
+	Name     string
+	Version  string
+	Provider string
+	// URL is the address or local file path of the box or its metadata. When empty, Name is looked up in the public
+	// box catalog. It is only used by BoxAdd.
+	URL string
+	// LatestVersion is the newest version available in the box catalog. It is only set by BoxOutdatedGlobal.
+	LatestVersion string
+}
+
+// checksumTypes are the checksum algorithms supported by vagrant box add.
+var checksumTypes = []string{"md5", "sha1", "sha256", "sha384", "sha512"}
+
+// BoxAddOptions configures the behavior of Vagrant.BoxAddWithOptions.
+type BoxAddOptions struct {
+	// Checksum is the expected checksum of the box file. The box is not added when the downloaded file does not
+	// match it.
+	Checksum string
+	// ChecksumType is the algorithm of Checksum: md5, sha1, sha256, sha384 or sha512. It is required with Checksum.
+	ChecksumType string
+	// Force replaces a box that is already installed with the same name, provider and version. Without it, a
+	// *BoxAlreadyExistsError is returned in that case.
+	Force bool
+	// Clean discards partially downloaded files from an earlier attempt instead of resuming the download.
+	Clean bool
+}
+
+// BoxAlreadyExistsError is returned by BoxAdd when the box is already installed and Force is not set.
+type BoxAlreadyExistsError struct {
+	// Name, Provider and Version identify the installed box, as reported by vagrant.
+	Name     string
+	Provider string
+	Version  string
+	// Err is the underlying command error.
+	Err error
+}
+
+func (e *BoxAlreadyExistsError) Error() string {
+	return fmt.Sprintf("vagrant box %s (%s, %s) already exists", e.Name, e.Provider, e.Version)
+}
+
+// Unwrap returns the underlying command error.
+func (e *BoxAlreadyExistsError) Unwrap() error {
+	return e.Err
+}
+
+// BoxList returns a list of all installed boxes along with their versions and providers.
+func (w wrapper) BoxList() ([]Box, error) {
+	return w.BoxListContext(context.Background())
+}
+
+// BoxListContext is like BoxList but includes a context.
+func (w wrapper) BoxListContext(ctx context.Context) (boxes []Box, err error) {
+	out, err := w.exec(ctx, "box", "list", "--machine-readable", "--no-color")
+	if err != nil {
+		return
+	}
+	boxInfo, err := parseMachineReadable(out)
+	if err != nil {
+		return
+	}
+
+	var box *Box
+	for _, entry := range boxInfo {
+		switch entry.mType {
+		case "box-name": // each box starts with its name
+			boxes = append(boxes, Box{Name: entry.data[0]})
+			box = &boxes[len(boxes)-1]
+		case "box-provider":
+			if box != nil {
+				box.Provider = entry.data[0]
+			}
+		case "box-version":
+			if box != nil {
+				box.Version = entry.data[0]
+			}
+		}
+	}
+	return
+}
+
+// BoxAdd installs a box by name, URL or local file path, optionally pinned to a specific version and provider.
+// A *BoxAlreadyExistsError is returned when the box is already installed.
+func (w wrapper) BoxAdd(box Box) error {
+	return w.BoxAddContext(context.Background(), box)
+}
+
+// BoxAddContext is like BoxAdd but includes a context.
+func (w wrapper) BoxAddContext(ctx context.Context, box Box) error {
+	return w.BoxAddWithOptionsContext(ctx, box, BoxAddOptions{})
+}
+
+// BoxAddWithOptions is like BoxAdd but allows verifying the checksum of the downloaded box and replacing a box that is
+// already installed.
+func (w wrapper) BoxAddWithOptions(box Box, opts BoxAddOptions) error {
+	return w.BoxAddWithOptionsContext(context.Background(), box, opts)
+}
+
+// BoxAddWithOptionsContext is like BoxAddWithOptions but includes a context.
+func (w wrapper) BoxAddWithOptionsContext(ctx context.Context, box Box, opts BoxAddOptions) error {
+	if len(box.Name) == 0 {
+		return errors.New("box must have a name")
+	}
+	cmdArgs := []string{"box", "add"}
+
+	if len(opts.Checksum) > 0 || len(opts.ChecksumType) > 0 {
+		if len(opts.Checksum) == 0 {
+			return errors.New("ChecksumType requires a Checksum")
+		}
+		if !slices.Contains(checksumTypes, opts.ChecksumType) {
+			return fmt.Errorf("invalid checksum type %q, must be one of %s", opts.ChecksumType,
+				strings.Join(checksumTypes, ", "))
+		}
+		cmdArgs = append(cmdArgs, "--checksum", opts.Checksum, "--checksum-type", opts.ChecksumType)
+	}
+	if opts.Force {
+		cmdArgs = append(cmdArgs, "--force")
+	}
+	if opts.Clean {
+		cmdArgs = append(cmdArgs, "--clean")
+	}
+
+	if len(box.Version) > 0 {
+		cmdArgs = append(cmdArgs, "--box-version", box.Version)
+	}
+	if len(box.Provider) > 0 {
+		cmdArgs = append(cmdArgs, "--provider", box.Provider)
+	}
+	if len(box.URL) > 0 {
+		cmdArgs = append(cmdArgs, "--name", box.Name, box.URL)
+	} else {
+		cmdArgs = append(cmdArgs, box.Name)
+	}
+
+	w.logger.Infof("Adding vagrant box: %s", box.Name)
+	return boxAddError(w.execLogOutput(ctx, cmdArgs...))
+}
+
+// boxAddError converts the error vagrant box add fails with when the box is already installed into a
+// *BoxAlreadyExistsError. Other errors are returned unchanged.
+func boxAddError(err error) error {
+	var ee command.ExitError
+	if !errors.As(err, &ee) || !boxExists.MatchString(ee.Stderr()) {
+		return err
+	}
+
+	existsErr := &BoxAlreadyExistsError{Err: err}
+	for _, m := range boxExistsField.FindAllStringSubmatch(ee.Stderr(), -1) {
+		switch m[1] {
+		case "Name":
+			existsErr.Name = m[2]
+		case "Provider":
+			existsErr.Provider = m[2]
+		case "Version":
+			existsErr.Version = m[2]
+		}
+	}
+	return existsErr
+}
+
+// BoxRemove removes an installed box. The removal is forced so it does not block on a confirmation prompt when the
+// box is still in use by an environment.
+func (w wrapper) BoxRemove(name string) error {
+	return w.BoxRemoveContext(context.Background(), name)
+}
+
+// BoxRemoveContext is like BoxRemove but includes a context.
+func (w wrapper) BoxRemoveContext(ctx context.Context, name string) error {
+	if len(name) == 0 {
+		return errors.New("box must have a name")
+	}
+
+	w.logger.Infof("Removing vagrant box: %s", name)
+	return w.execLogOutput(ctx, "box", "remove", name, "--force")
+}
+
+// BoxUpdate updates the box used by the current environment to the latest available version.
+func (w wrapper) BoxUpdate() error {
+	return w.BoxUpdateContext(context.Background())
+}
+
+// BoxUpdateContext is like BoxUpdate but includes a context.
+func (w wrapper) BoxUpdateContext(ctx context.Context) error {
+	w.logger.Infof("Updating vagrant box")
+	return w.execLogOutput(ctx, "box", "update")
+}
+
+// BoxOutdated checks if a newer version of the box used by the current environment is available. Boxes that were not
+// added from a catalog have no version information and are reported as up to date. The box is also reported as
+// outdated when the command exits with a code accepted with WithAcceptedExitCodes("box outdated", ...).
+func (w wrapper) BoxOutdated() (bool, error) {
+	return w.BoxOutdatedContext(context.Background())
+}
+
+// BoxOutdatedContext is like BoxOutdated but includes a context.
+func (w wrapper) BoxOutdatedContext(ctx context.Context) (outdated bool, err error) {
+	out, code, err := w.execExitCode(ctx, "box", "outdated", "--machine-readable", "--no-color")
+	if err != nil {
+		return
+	}
+	outdated = code != 0
+	entries, err := parseMachineReadable(out)
+	if err != nil {
+		return
+	}
+
+	for _, msg := range uiMessages(entries) {
+		if boxOutdated.MatchString(msg) {
+			outdated = true
+		}
+	}
+	return
+}
+
+// BoxOutdatedGlobal returns the installed boxes that have a newer version available, with Version set to the
+// installed version and LatestVersion to the newest one. Boxes that were not added from a catalog have no version
+// information and are skipped.
+func (w wrapper) BoxOutdatedGlobal() ([]Box, error) {
+	return w.BoxOutdatedGlobalContext(context.Background())
+}
+
+// BoxOutdatedGlobalContext is like BoxOutdatedGlobal but includes a context.
+func (w wrapper) BoxOutdatedGlobalContext(ctx context.Context) (boxes []Box, err error) {
+	out, err := w.exec(ctx, "box", "outdated", "--global", "--machine-readable", "--no-color")
+	if err != nil {
+		return
+	}
+	entries, err := parseMachineReadable(out)
+	if err != nil {
+		return
+	}
+
+	for _, msg := range uiMessages(entries) {
+		for _, line := range strings.Split(msg, "\n") {
+			line = strings.TrimSpace(line)
+			if m := globalBoxOutdated.FindStringSubmatch(line); m != nil {
+				boxes = append(boxes, Box{Name: m[1], Provider: m[2], Version: m[3], LatestVersion: m[4]})
+			} else if m := globalBoxNoMetadata.FindStringSubmatch(line); m != nil {
+				w.logger.Debugf("Box %s for %s has no version information, skipping update check", m[1], m[2])
+			}
+		}
+	}
+	return
+}
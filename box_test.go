@@ -0,0 +1,244 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoxList(t *testing.T) {
+	mockBoxList := mockedWrapperFn([]string{"box", "list", "--machine-readable", "--no-color"})
+
+	t.Run("with_boxes", func(t *testing.T) {
+		w := mockBoxList(ioutil.ReadFile("testdata/box-list"))
+
+		actual, err := w.BoxList()
+		require.NoError(t, err)
+
+		expected := []Box{
+			{
+				Name:     "hashicorp/bionic64",
+				Version:  "1.0.282",
+				Provider: "virtualbox",
+			},
+			{
+				Name:     "generic/ubuntu1804",
+				Version:  "1.9.18",
+				Provider: "libvirt",
+			},
+		}
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("no_boxes", func(t *testing.T) {
+		w := mockBoxList(ioutil.ReadFile("testdata/box-list-none"))
+
+		actual, err := w.BoxList()
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockBoxList(nil, errors.New("runner error"))
+
+		_, err := w.BoxList()
+		assert.Error(t, err)
+	})
+}
+
+func TestBoxAdd(t *testing.T) {
+	mockBoxAdd := mockedWrapperFn([]string{"box", "add", "hashicorp/bionic64"})
+
+	box := Box{Name: "hashicorp/bionic64"}
+	t.Run("success", func(t *testing.T) {
+		w := mockBoxAdd(nil, nil)
+		assert.NoError(t, w.BoxAdd(box))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockBoxAdd(nil, errors.New("runner error"))
+		assert.Error(t, w.BoxAdd(box))
+	})
+
+	t.Run("no_name", func(t *testing.T) {
+		w := mockBoxAdd(nil, nil)
+
+		err := w.BoxAdd(Box{URL: "https://example.com/my.box"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "box must have a name")
+	})
+
+	t.Run("with_pins", func(t *testing.T) {
+		w := mockedWrapperFn([]string{
+			"box", "add", "--box-version", "1.0.282", "--provider", "virtualbox", "hashicorp/bionic64",
+		})(nil, nil)
+
+		assert.NoError(t, w.BoxAdd(Box{Name: "hashicorp/bionic64", Version: "1.0.282", Provider: "virtualbox"}))
+	})
+
+	t.Run("from_url", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"box", "add", "--name", "my-box", "/tmp/my.box"})(nil, nil)
+
+		assert.NoError(t, w.BoxAdd(Box{Name: "my-box", URL: "/tmp/my.box"}))
+	})
+}
+
+func TestBoxAddWithOptions(t *testing.T) {
+	box := Box{Name: "my-box", URL: "https://example.com/my.box"}
+	checksum := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	t.Run("checksum", func(t *testing.T) {
+		w := mockedWrapperFn([]string{
+			"box", "add", "--checksum", checksum, "--checksum-type", "sha256", "--name", "my-box", "https://example.com/my.box",
+		})(nil, nil)
+
+		assert.NoError(t, w.BoxAddWithOptions(box, BoxAddOptions{Checksum: checksum, ChecksumType: "sha256"}))
+	})
+
+	t.Run("invalid_checksum_type", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"box", "add"})(nil, nil)
+
+		err := w.BoxAddWithOptions(box, BoxAddOptions{Checksum: checksum, ChecksumType: "crc32"})
+		require.Error(t, err)
+		assert.Equal(t, `invalid checksum type "crc32", must be one of md5, sha1, sha256, sha384, sha512`, err.Error())
+
+		assert.Error(t, w.BoxAddWithOptions(box, BoxAddOptions{Checksum: checksum}))
+	})
+
+	t.Run("force_and_clean", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"box", "add", "--force", "--clean", "--name", "my-box", "https://example.com/my.box"})(nil, nil)
+		assert.NoError(t, w.BoxAddWithOptions(box, BoxAddOptions{Force: true, Clean: true}))
+	})
+
+	t.Run("already_exists", func(t *testing.T) {
+		cmdErr := command.NewExitError("vagrant", 1, "The box you're attempting to add already exists. Remove it before\n"+
+			"adding it again or add it with the `--force` flag.\n\nName: my-box\nProvider: virtualbox\nVersion: 0\n")
+		w := mockedWrapperFn([]string{"box", "add", "--name", "my-box", "https://example.com/my.box"})(nil, cmdErr)
+
+		err := w.BoxAdd(box)
+		var existsErr *BoxAlreadyExistsError
+		require.True(t, errors.As(err, &existsErr))
+		assert.Equal(t, "my-box", existsErr.Name)
+		assert.Equal(t, "virtualbox", existsErr.Provider)
+		assert.Equal(t, "0", existsErr.Version)
+		assert.Equal(t, "vagrant box my-box (virtualbox, 0) already exists", err.Error())
+		assert.True(t, errors.Is(err, cmdErr))
+	})
+
+	t.Run("type_without_checksum", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"box", "add"})(nil, nil)
+		assert.Error(t, w.BoxAddWithOptions(box, BoxAddOptions{ChecksumType: "sha256"}))
+	})
+}
+
+func TestBoxRemove(t *testing.T) {
+	mockBoxRemove := mockedWrapperFn([]string{"box", "remove", "my-box", "--force"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockBoxRemove(nil, nil)
+		assert.NoError(t, w.BoxRemove("my-box"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockBoxRemove(nil, errors.New("runner error"))
+		assert.Error(t, w.BoxRemove("my-box"))
+	})
+
+	t.Run("no_name", func(t *testing.T) {
+		w := mockBoxRemove(nil, nil)
+		assert.Error(t, w.BoxRemove(""))
+	})
+}
+
+func TestBoxUpdate(t *testing.T) {
+	mockBoxUpdate := mockedWrapperFn([]string{"box", "update"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockBoxUpdate([]byte("update output"), nil)
+		assert.NoError(t, w.BoxUpdate())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockBoxUpdate(nil, errors.New("runner error"))
+		assert.Error(t, w.BoxUpdate())
+	})
+}
+
+func TestBoxOutdated(t *testing.T) {
+	mockBoxOutdated := mockedWrapperFn([]string{"box", "outdated", "--machine-readable", "--no-color"})
+
+	t.Run("outdated", func(t *testing.T) {
+		w := mockBoxOutdated(ioutil.ReadFile("testdata/box-outdated"))
+
+		outdated, err := w.BoxOutdated()
+		require.NoError(t, err)
+		assert.True(t, outdated)
+	})
+
+	t.Run("up_to_date", func(t *testing.T) {
+		w := mockBoxOutdated(ioutil.ReadFile("testdata/box-outdated-current"))
+
+		outdated, err := w.BoxOutdated()
+		require.NoError(t, err)
+		assert.False(t, outdated)
+	})
+
+	t.Run("accepted_exit_code", func(t *testing.T) {
+		w := mockBoxOutdated(nil, command.NewExitError("vagrant", 3, ""))
+		WithAcceptedExitCodes("box outdated", 3)(&w)
+
+		outdated, err := w.BoxOutdated()
+		require.NoError(t, err)
+		assert.True(t, outdated)
+	})
+
+	t.Run("no_metadata", func(t *testing.T) {
+		w := mockBoxOutdated(nil, nil)
+
+		outdated, err := w.BoxOutdated()
+		require.NoError(t, err)
+		assert.False(t, outdated)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockBoxOutdated(nil, errors.New("runner error"))
+
+		_, err := w.BoxOutdated()
+		assert.Error(t, err)
+	})
+}
+
+func TestBoxOutdatedGlobal(t *testing.T) {
+	mockBoxOutdated := mockedWrapperFn([]string{"box", "outdated", "--global", "--machine-readable", "--no-color"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockBoxOutdated(ioutil.ReadFile("testdata/box-outdated-global"))
+		logger := &recordingLogger{}
+		w.logger = logger
+
+		actual, err := w.BoxOutdatedGlobal()
+		require.NoError(t, err)
+
+		expected := []Box{
+			{
+				Name:          "hashicorp/bionic64",
+				Version:       "1.0.282",
+				Provider:      "virtualbox",
+				LatestVersion: "1.0.283",
+			},
+		}
+		assert.Equal(t, expected, actual)
+		assert.Contains(t, logger.lines, "DEBUG Box local/centos for virtualbox has no version information, skipping update check")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockBoxOutdated(nil, errors.New("runner error"))
+
+		_, err := w.BoxOutdatedGlobal()
+		assert.Error(t, err)
+	})
+}
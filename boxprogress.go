@@ -0,0 +1,96 @@
+package vagrantexec
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	boxAddStarted  = regexp.MustCompile(`Box '([^']+)' could not be found|Adding box '([^']+)'|Loading metadata for box '([^']+)'`)
+	boxAddFinished = regexp.MustCompile(`Successfully added box '([^']+)'|Importing base box '([^']+)'`)
+)
+
+// boxProgressParser follows the box download reported in the machine-readable output of up and reports its
+// percentage to a callback. Vagrant names the box once before the download starts and only prints the percentage in
+// the progress entries that follow.
+type boxProgressParser struct {
+	box        string
+	pct        int
+	onProgress func(box string, pct int)
+}
+
+// newBoxProgressParser returns a parser that calls onProgress with every new percentage.
+func newBoxProgressParser(onProgress func(box string, pct int)) *boxProgressParser {
+	return &boxProgressParser{pct: -1, onProgress: onProgress}
+}
+
+// parse handles a single machine-readable entry. Once the box has been added vagrant moves on to importing it, at
+// which point 100 is reported if the last progress entry showed less.
+func (p *boxProgressParser) parse(entry machineOutputEntry) {
+	if entry.mType != "ui" || len(entry.data) < 2 {
+		return
+	}
+	message := strings.Join(entry.data[1:], ",")
+
+	if m := boxDownloadProgress.FindStringSubmatch(message); m != nil {
+		pct, _ := strconv.Atoi(m[1])
+		p.report(pct)
+	} else if m := boxAddFinished.FindStringSubmatch(message); m != nil {
+		if p.pct >= 0 {
+			p.report(100)
+		}
+		p.box, p.pct = "", -1
+	} else if m := boxAddStarted.FindStringSubmatch(message); m != nil {
+		p.box, p.pct = firstSubmatch(m), -1
+	}
+}
+
+// report calls the callback unless pct was already reported for the current box.
+func (p *boxProgressParser) report(pct int) {
+	if pct == p.pct {
+		return
+	}
+	p.pct = pct
+	p.onProgress(p.box, pct)
+}
+
+// firstSubmatch returns the first non-empty capture group of a match.
+func firstSubmatch(m []string) string {
+	for _, s := range m[1:] {
+		if len(s) > 0 {
+			return s
+		}
+	}
+	return ""
+}
+
+// upWithBoxProgress runs up with machine-readable output, which it consumes line by line as vagrant prints it to
+// report the box download progress. The ui messages are logged in place of the raw output.
+func (w wrapper) upWithBoxProgress(ctx context.Context, cmdArgs []string, onProgress func(box string, pct int)) error {
+	parser := newBoxProgressParser(onProgress)
+	lw := &lineWriter{done: ctx.Done(), onLine: func(line string) {
+		entries, err := parseMachineReadable([]byte(line))
+		if err != nil {
+			w.logger.Debugf("Skipping up output line: %s", line)
+			return
+		}
+		for _, entry := range entries {
+			parser.parse(entry)
+		}
+		for _, message := range uiMessages(entries) {
+			w.logger.Infof("%s", message)
+		}
+	}}
+	if w.stdout != nil {
+		w.stdout = io.MultiWriter(w.stdout, lw)
+	} else {
+		w.stdout = lw
+	}
+
+	_, err := w.exec(ctx, append([]string{"up", "--machine-readable", "--no-color"}, cmdArgs...)...)
+	lw.Flush()
+	return err
+}
@@ -0,0 +1,84 @@
+package vagrantexec
+
+import (
+	"context"
+	"sync"
+)
+
+// processRegistry tracks the long-running vagrant processes started by a wrapper, such as those behind RSyncAuto,
+// Connect and UpEvents, so that Close can stop them.
+type processRegistry struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	next    int
+	cancels map[int]context.CancelFunc
+	stopped bool
+}
+
+// track returns a context derived from ctx that is also cancelled by Close. The returned function must be called once
+// the process has exited and releases the context. ctx is returned unchanged when r is nil, and an already cancelled
+// context is returned once stop has been called, so that no process is started after Close.
+func (r *processRegistry) track(ctx context.Context) (context.Context, func()) {
+	if r == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		cancel()
+		return ctx, cancel
+	}
+	if r.cancels == nil {
+		r.cancels = map[int]context.CancelFunc{}
+	}
+	id := r.next
+	r.next++
+	r.cancels[id] = cancel
+	r.wg.Add(1)
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, id)
+		r.mu.Unlock()
+		cancel()
+		r.wg.Done()
+	}
+}
+
+// stop cancels every tracked process, refuses to track new ones and waits for the tracked processes to exit.
+func (r *processRegistry) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	for _, cancel := range r.cancels {
+		cancel()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// Close stops the background vagrant processes started by the wrapper and waits for them to exit. This includes the
+// shares started with ShareStart and the commands run by RSyncAuto, Connect and UpEvents, which return as if their
+// context had been cancelled. Other commands can still be run afterwards, but ShareStart, RSyncAuto, Connect and
+// UpEvents return immediately as if their context had been cancelled. Wrappers returned by Environment share their background
+// processes with the wrapper they were created from.
+func (w wrapper) Close() error {
+	if w.shares != nil {
+		w.shares.mu.Lock()
+		shares := w.shares.shares
+		w.shares.shares = nil
+		w.shares.mu.Unlock()
+
+		for name, share := range shares {
+			w.logger.Infof("Stopping vagrant share: %s", name)
+			share.cancel()
+			<-share.done
+		}
+	}
+	if w.procs != nil {
+		w.procs.stop()
+	}
+	return nil
+}
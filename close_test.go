@@ -0,0 +1,97 @@
+package vagrantexec
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startedWriter closes started on the first write, which tells the test that a command is running.
+type startedWriter struct {
+	once    sync.Once
+	started chan struct{}
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	return len(p), nil
+}
+
+// waitStarted blocks until the command writing to sw has started. The test fails instead of hanging when the command
+// exits first or never starts.
+func waitStarted(t *testing.T, sw *startedWriter, exited <-chan error) {
+	t.Helper()
+	select {
+	case <-sw.started:
+	case err := <-exited:
+		t.Fatalf("command exited before it started: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not start")
+	}
+}
+
+func TestClose(t *testing.T) {
+	newCloseWrapper := func(fixture string) (wrapper, *longRunningRunner) {
+		output, err := ioutil.ReadFile(fixture)
+		require.NoError(t, err)
+
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+		runner := &longRunningRunner{output: output, stopped: make(chan struct{})}
+		w := New(".", false, WithRunner(runner), WithLogger(logger)).(wrapper)
+		w.dir = ""
+		return w, runner
+	}
+
+	t.Run("stops_shares", func(t *testing.T) {
+		w, runner := newCloseWrapper("testdata/share")
+
+		name, err := w.ShareStart(context.Background(), ShareOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+		<-runner.stopped
+		assert.Error(t, w.ShareStop(name))
+	})
+
+	t.Run("stops_rsync_auto", func(t *testing.T) {
+		w, runner := newCloseWrapper("testdata/share")
+		sw := &startedWriter{started: make(chan struct{})}
+		w.stdout = sw
+
+		exited := make(chan error, 1)
+		go func() { exited <- w.RSyncAuto(context.Background()) }()
+		waitStarted(t, sw, exited)
+
+		require.NoError(t, w.Close())
+		<-runner.stopped
+		assert.NoError(t, <-exited)
+	})
+
+	t.Run("environment_shares_processes", func(t *testing.T) {
+		w, runner := newCloseWrapper("testdata/share")
+		sw := &startedWriter{started: make(chan struct{})}
+		w.stdout = sw
+
+		env := w.Environment("testdata/env")
+		exited := make(chan error, 1)
+		go func() { exited <- env.RSyncAuto(context.Background()) }()
+		waitStarted(t, sw, exited)
+
+		require.NoError(t, w.Close())
+		<-runner.stopped
+		assert.NoError(t, <-exited)
+	})
+
+	t.Run("nothing_running", func(t *testing.T) {
+		w, _ := newCloseWrapper("testdata/share")
+		assert.NoError(t, w.Close())
+		assert.NoError(t, wrapper{}.Close())
+	})
+}
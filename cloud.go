@@ -0,0 +1,33 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+)
+
+// CloudLogin authenticates with Vagrant Cloud using an access token so that private boxes can be downloaded. The
+// token is never logged.
+func (w wrapper) CloudLogin(token string) error {
+	return w.CloudLoginContext(context.Background(), token)
+}
+
+// CloudLoginContext is like CloudLogin but includes a context.
+func (w wrapper) CloudLoginContext(ctx context.Context, token string) error {
+	if len(token) == 0 {
+		return errors.New("vagrant cloud token cannot be empty")
+	}
+
+	w.logger.Infof("Logging in to vagrant cloud")
+	return w.execLogOutput(ctx, "cloud", "auth", "login", "--token", token)
+}
+
+// CloudLogout removes the Vagrant Cloud access token stored by CloudLogin.
+func (w wrapper) CloudLogout() error {
+	return w.CloudLogoutContext(context.Background())
+}
+
+// CloudLogoutContext is like CloudLogout but includes a context.
+func (w wrapper) CloudLogoutContext(ctx context.Context) error {
+	w.logger.Infof("Logging out of vagrant cloud")
+	return w.execLogOutput(ctx, "cloud", "auth", "logout")
+}
@@ -0,0 +1,57 @@
+package vagrantexec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudLogin(t *testing.T) {
+	mockLogin := mockedWrapperFn([]string{"cloud", "auth", "login", "--token", "s3cr3t"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockLogin([]byte("You are now logged in."), nil)
+		assert.NoError(t, w.CloudLogin("s3cr3t"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockLogin(nil, errors.New("runner error"))
+		assert.Error(t, w.CloudLogin("s3cr3t"))
+	})
+
+	t.Run("empty_token", func(t *testing.T) {
+		w := mockLogin(nil, nil)
+		assert.Error(t, w.CloudLogin(""))
+	})
+
+	t.Run("token_not_logged", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: []byte("You are now logged in.")}, "vagrant", "cloud", "auth", "login", "--token", "s3cr3t")
+		logger := &recordingLogger{}
+		w := wrapper{executable: binary, runner: runner, logger: logger}
+
+		require.NoError(t, w.CloudLogin("s3cr3t"))
+		assert.Contains(t, logger.lines, "DEBUG Running command [vagrant cloud auth login --token ***]")
+		for _, line := range logger.lines {
+			assert.False(t, strings.Contains(line, "s3cr3t"), "token leaked in %q", line)
+		}
+	})
+}
+
+func TestCloudLogout(t *testing.T) {
+	mockLogout := mockedWrapperFn([]string{"cloud", "auth", "logout"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockLogout([]byte("You are logged out."), nil)
+		assert.NoError(t, w.CloudLogout())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockLogout(nil, errors.New("runner error"))
+		assert.Error(t, w.CloudLogout())
+	})
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// CombinedRunner is implemented by runners that can capture standard output and standard error through a single pipe,
+// preserving the exact order in which a command wrote them.
+type CombinedRunner interface {
+	ExecuteCombined(ctx context.Context, cmd string, args ...string) ([]byte, error)
+}
+
+// CombinedOutput runs a command and returns its standard output and standard error combined. Runners implementing
+// CombinedRunner are used directly; for other runners both streams are collected through ExecuteStream, which keeps
+// the order of writes but may interleave them differently than a terminal would.
+func CombinedOutput(ctx context.Context, r Runner, cmd string, args ...string) ([]byte, error) {
+	if cr, ok := r.(CombinedRunner); ok {
+		return cr.ExecuteCombined(ctx, cmd, args...)
+	}
+
+	var buf lockedBuffer
+	err := r.ExecuteStream(ctx, &buf, &buf, cmd, args...)
+	return buf.Bytes(), err
+}
+
+// CombinedOutputStream behaves like CombinedOutput but also writes standard output and standard error to the provided
+// writers as the command produces them. Either writer may be nil to discard that stream. The output is always collected
+// through ExecuteStream so that it can be streamed, even for runners implementing CombinedRunner.
+func CombinedOutputStream(ctx context.Context, r Runner, stdout, stderr io.Writer, cmd string, args ...string) ([]byte, error) {
+	var buf lockedBuffer
+	outW, errW := io.Writer(&buf), io.Writer(&buf)
+	if stdout != nil {
+		outW = io.MultiWriter(&buf, stdout)
+	}
+	if stderr != nil {
+		errW = io.MultiWriter(&buf, stderr)
+	}
+
+	err := r.ExecuteStream(ctx, outW, errW, cmd, args...)
+	return buf.Bytes(), err
+}
+
+// lockedBuffer is a bytes.Buffer that can be written to from multiple goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombinedOutput(t *testing.T) {
+	t.Run("combined_runner", func(t *testing.T) {
+		out, err := CombinedOutput(context.Background(), ShellRunner{}, "sh", "-c", "echo out && echo err >&2")
+
+		require.NoError(t, err)
+		assert.Equal(t, "out\nerr\n", string(out))
+	})
+
+	t.Run("stream_fallback", func(t *testing.T) {
+		runner := &MockRunner{}
+		runner.AddResponse(Response{Output: []byte("mock output")}, "vagrant", "provision")
+
+		out, err := CombinedOutput(context.Background(), runner, "vagrant", "provision")
+
+		require.NoError(t, err)
+		assert.Equal(t, "mock output", string(out))
+	})
+}
+
+func TestCombinedOutputStream(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	out, err := CombinedOutputStream(context.Background(), ShellRunner{}, &stdout, &stderr, "sh", "-c", "echo out && sleep 0.1 && echo err >&2")
+
+	require.NoError(t, err)
+	assert.Equal(t, "out\nerr\n", string(out))
+	assert.Equal(t, "out\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+}
@@ -6,8 +6,16 @@ import (
 )
 
 // ExitError is created whenever a command exits with a non-zero status.
+//
+// Vagrant itself exits with status 1 for nearly every failure, so the exit code alone rarely identifies the cause;
+// the standard error output returned by Stderr is more specific. Known exceptions are:
+//
+//   - vagrant ssh -c exits with the status of the remote command.
+//   - vagrant status exits with status 0 even when machines are not created.
+//   - A process killed by a signal reports a status of -1.
 type ExitError struct {
 	msg        string
+	stderr     string
 	exitStatus int
 }
 
@@ -20,10 +28,39 @@ func (e ExitError) ExitStatus() int {
 	return e.exitStatus
 }
 
-// newExistError creates a new ExitError with a descriptive message.
-func newExitError(cmd string, exitStatus int, msg string) ExitError {
+// ExitCode returns the exit code of the exited process. It is an alias for ExitStatus that matches the naming used
+// by os.ProcessState.
+func (e ExitError) ExitCode() int {
+	return e.exitStatus
+}
+
+// Stderr returns the standard error output of the exited process.
+func (e ExitError) Stderr() string {
+	return e.stderr
+}
+
+// NewExitError creates a new ExitError with a descriptive message. It is exported so that Runner implementations and
+// test doubles can produce the same errors as ShellRunner.
+func NewExitError(cmd string, exitStatus int, stderr string) ExitError {
 	return ExitError{
-		msg:        fmt.Sprintf("%s exited with status %d: %s", cmd, exitStatus, strings.TrimSpace(msg)),
+		msg:        fmt.Sprintf("%s exited with status %d: %s", cmd, exitStatus, strings.TrimSpace(stderr)),
+		stderr:     stderr,
 		exitStatus: exitStatus,
 	}
 }
+
+// BinaryNotFoundError is returned when a command cannot be started because its executable does not exist, cannot be
+// found in the PATH or is not executable.
+type BinaryNotFoundError struct {
+	Name string
+	Err  error
+}
+
+func (e *BinaryNotFoundError) Error() string {
+	return fmt.Sprintf("%s is not installed or not executable: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error, usually an *exec.Error or *fs.PathError.
+func (e *BinaryNotFoundError) Unwrap() error {
+	return e.Err
+}
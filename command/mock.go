@@ -0,0 +1,103 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+var _ Runner = (*MockRunner)(nil)
+
+// Invocation records a single command executed by a MockRunner.
+type Invocation struct {
+	Cmd  string
+	Args []string
+}
+
+// String returns the full command line of the invocation.
+func (i Invocation) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", i.Cmd, strings.Join(i.Args, " ")))
+}
+
+// Response is the canned result a MockRunner returns for a command.
+type Response struct {
+	Output []byte
+	Err    error
+}
+
+// MockRunner is a Runner test double that records every command it is asked to run and replies with canned
+// responses instead of executing anything. The zero value is ready to use and reports success with no output for
+// every command. It is safe for concurrent use.
+type MockRunner struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	calls     []Invocation
+}
+
+// AddResponse queues a response for the command line formed by cmd and args. Responses queued for the same command
+// line are returned in order, and the last one is repeated once the queue is exhausted.
+func (m *MockRunner) AddResponse(resp Response, cmd string, args ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.responses == nil {
+		m.responses = map[string][]Response{}
+	}
+	key := Invocation{Cmd: cmd, Args: args}.String()
+	m.responses[key] = append(m.responses[key], resp)
+}
+
+// Calls returns every invocation recorded so far, in the order it was received.
+func (m *MockRunner) Calls() []Invocation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Invocation(nil), m.calls...)
+}
+
+// Execute records the invocation and returns the next queued response for it.
+func (m *MockRunner) Execute(cmd string, args ...string) ([]byte, error) {
+	return m.ExecuteContext(context.Background(), cmd, args...)
+}
+
+// ExecuteContext records the invocation and returns the next queued response for it. An error wrapping ctx.Err() is
+// returned instead when the context is already done.
+func (m *MockRunner) ExecuteContext(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	resp := m.record(cmd, args)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("%s interrupted: %w", cmd, ctxErr)
+	}
+	return resp.Output, resp.Err
+}
+
+// ExecuteStream records the invocation and writes the output of the next queued response for it to stdout.
+func (m *MockRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	out, err := m.ExecuteContext(ctx, cmd, args...)
+	if stdout != nil && len(out) > 0 {
+		if _, wErr := stdout.Write(out); wErr != nil {
+			return wErr
+		}
+	}
+	return err
+}
+
+// record appends the invocation to the call history and dequeues its response.
+func (m *MockRunner) record(cmd string, args []string) Response {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inv := Invocation{Cmd: cmd, Args: append([]string(nil), args...)}
+	m.calls = append(m.calls, inv)
+
+	key := inv.String()
+	queue := m.responses[key]
+	if len(queue) == 0 {
+		return Response{}
+	}
+	if len(queue) > 1 {
+		m.responses[key] = queue[1:]
+	}
+	return queue[0]
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockRunner(t *testing.T) {
+	t.Run("zero_value", func(t *testing.T) {
+		var m MockRunner
+		out, err := m.Execute("vagrant", "up")
+
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("canned_response", func(t *testing.T) {
+		var m MockRunner
+		m.AddResponse(Response{Output: []byte("2.2.5")}, "vagrant", "version")
+		m.AddResponse(Response{Err: errors.New("up failed")}, "vagrant", "up")
+
+		out, err := m.Execute("vagrant", "version")
+		require.NoError(t, err)
+		assert.Equal(t, "2.2.5", string(out))
+
+		_, err = m.Execute("vagrant", "up")
+		assert.EqualError(t, err, "up failed")
+	})
+
+	t.Run("queued_responses", func(t *testing.T) {
+		var m MockRunner
+		m.AddResponse(Response{Err: errors.New("transient")}, "vagrant", "up")
+		m.AddResponse(Response{Output: []byte("first")}, "vagrant", "up")
+		m.AddResponse(Response{Output: []byte("last")}, "vagrant", "up")
+
+		_, err := m.Execute("vagrant", "up")
+		assert.Error(t, err)
+
+		for _, expected := range []string{"first", "last", "last"} {
+			out, err := m.Execute("vagrant", "up")
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(out))
+		}
+	})
+
+	t.Run("call_history", func(t *testing.T) {
+		var m MockRunner
+		m.Execute("vagrant", "up", "web")
+		m.ExecuteContext(context.Background(), "vagrant", "halt")
+
+		expected := []Invocation{
+			{Cmd: "vagrant", Args: []string{"up", "web"}},
+			{Cmd: "vagrant", Args: []string{"halt"}},
+		}
+		assert.Equal(t, expected, m.Calls())
+		assert.Equal(t, "vagrant up web", m.Calls()[0].String())
+	})
+
+	t.Run("cancelled_context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var m MockRunner
+		_, err := m.ExecuteContext(ctx, "vagrant", "up")
+
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Len(t, m.Calls(), 1)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var m MockRunner
+		m.AddResponse(Response{Output: []byte("streamed")}, "vagrant", "up")
+
+		var stdout bytes.Buffer
+		err := m.ExecuteStream(context.Background(), &stdout, nil, "vagrant", "up")
+
+		require.NoError(t, err)
+		assert.Equal(t, "streamed", stdout.String())
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package command
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroupOnCancel starts the command in its own process group and sends SIGKILL to the entire group when the
+// command's context is done. Vagrant forks provider tools (VBoxManage, ssh, rsync, etc.) that would otherwise outlive
+// the vagrant process and hold its output pipes open.
+func killProcessGroupOnCancel(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package command
+
+import "os/exec"
+
+// killProcessGroupOnCancel is a no-op on Windows, where process groups cannot be signalled. The default behavior of
+// exec.CommandContext, which kills the vagrant process itself, applies.
+func killProcessGroupOnCancel(c *exec.Cmd) {}
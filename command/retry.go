@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var _ Runner = RetryRunner{}
+
+// RetryRunner wraps another Runner and re-runs commands that fail with a retryable error. Output from failed attempts
+// is discarded, so ExecuteStream, which cannot take back output already written, is never retried.
+type RetryRunner struct {
+	// Runner executes the commands. A ShellRunner is used when it is nil.
+	Runner Runner
+	// Attempts is the total number of times a command is run. Commands are run once when it is less than 2.
+	Attempts int
+	// Backoff is the delay before the second attempt. It doubles after every failure.
+	Backoff time.Duration
+	// Retryable reports whether a failed command should be run again. When it is nil, every ExitError is retried.
+	// Errors caused by a cancelled context are never retried.
+	Retryable func(error) bool
+}
+
+// Execute runs a command through the wrapped runner, retrying it according to the runner's policy.
+func (r RetryRunner) Execute(cmd string, args ...string) ([]byte, error) {
+	return r.ExecuteContext(context.Background(), cmd, args...)
+}
+
+// ExecuteContext is like Execute but includes a context. A cancelled context stops any further attempts.
+func (r RetryRunner) ExecuteContext(ctx context.Context, cmd string, args ...string) (out []byte, err error) {
+	err = r.run(ctx, func() (err error) {
+		out, err = r.runner().ExecuteContext(ctx, cmd, args...)
+		return
+	})
+	return
+}
+
+// ExecuteStream runs a command through the wrapped runner once, without retries.
+func (r RetryRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	return r.runner().ExecuteStream(ctx, stdout, stderr, cmd, args...)
+}
+
+// ExecuteCombined is like ExecuteContext but returns standard output and standard error combined. See CombinedOutput.
+func (r RetryRunner) ExecuteCombined(ctx context.Context, cmd string, args ...string) (out []byte, err error) {
+	err = r.run(ctx, func() (err error) {
+		out, err = CombinedOutput(ctx, r.runner(), cmd, args...)
+		return
+	})
+	return
+}
+
+// run invokes fn until it succeeds, returns a non-retryable error or the attempts are exhausted.
+func (r RetryRunner) run(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt < r.Attempts && err != nil && r.retryable(ctx, err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(r.Backoff << uint(attempt-1)):
+		}
+		err = fn()
+	}
+	return err
+}
+
+func (r RetryRunner) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if r.Retryable != nil {
+		return r.Retryable(err)
+	}
+	var ee ExitError
+	return errors.As(err, &ee)
+}
+
+func (r RetryRunner) runner() Runner {
+	if r.Runner == nil {
+		return ShellRunner{}
+	}
+	return r.Runner
+}
@@ -0,0 +1,111 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryRunner(t *testing.T) {
+	transient := NewExitError("vagrant", 1, "An error occurred while downloading the remote file.")
+	fatal := NewExitError("vagrant", 1, "There is a syntax error in the following Vagrantfile.")
+	isTransient := func(err error) bool {
+		var ee ExitError
+		return errors.As(err, &ee) && strings.Contains(ee.Stderr(), "downloading")
+	}
+
+	t.Run("succeeds_after_retries", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: transient}, "vagrant", "up")
+		mock.AddResponse(Response{Err: transient}, "vagrant", "up")
+		mock.AddResponse(Response{Output: []byte("done")}, "vagrant", "up")
+
+		rr := RetryRunner{Runner: mock, Attempts: 5, Backoff: time.Millisecond, Retryable: isTransient}
+		out, err := rr.Execute("vagrant", "up")
+
+		require.NoError(t, err)
+		assert.Equal(t, "done", string(out))
+		assert.Len(t, mock.Calls(), 3)
+	})
+
+	t.Run("attempts_exhausted", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: transient}, "vagrant", "up")
+
+		rr := RetryRunner{Runner: mock, Attempts: 3, Backoff: time.Millisecond, Retryable: isTransient}
+		_, err := rr.Execute("vagrant", "up")
+
+		assert.Equal(t, transient, err)
+		assert.Len(t, mock.Calls(), 3)
+	})
+
+	t.Run("not_retryable", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: fatal}, "vagrant", "up")
+
+		rr := RetryRunner{Runner: mock, Attempts: 3, Backoff: time.Millisecond, Retryable: isTransient}
+		_, err := rr.Execute("vagrant", "up")
+
+		assert.Equal(t, fatal, err)
+		assert.Len(t, mock.Calls(), 1)
+	})
+
+	t.Run("default_predicate", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: fatal}, "vagrant", "up")
+		mock.AddResponse(Response{Err: errors.New("not an exit error")}, "vagrant", "up")
+
+		rr := RetryRunner{Runner: mock, Attempts: 3, Backoff: time.Millisecond}
+		_, err := rr.Execute("vagrant", "up")
+
+		assert.EqualError(t, err, "not an exit error")
+		assert.Len(t, mock.Calls(), 2)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: transient}, "vagrant", "up")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		rr := RetryRunner{Runner: mock, Attempts: 3, Backoff: time.Minute, Retryable: isTransient}
+		_, err := rr.ExecuteContext(ctx, "vagrant", "up")
+
+		assert.Equal(t, transient, err)
+		assert.Len(t, mock.Calls(), 1)
+	})
+
+	t.Run("stream_not_retried", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: transient}, "vagrant", "up")
+
+		rr := RetryRunner{Runner: mock, Attempts: 3, Backoff: time.Millisecond}
+		err := rr.ExecuteStream(context.Background(), nil, nil, "vagrant", "up")
+
+		assert.Equal(t, transient, err)
+		assert.Len(t, mock.Calls(), 1)
+	})
+
+	t.Run("composes_with_timeout", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Err: transient}, "vagrant", "up")
+		mock.AddResponse(Response{Output: []byte("done")}, "vagrant", "up")
+
+		rr := RetryRunner{
+			Runner:   TimeoutRunner{Runner: mock, Timeout: time.Second},
+			Attempts: 2,
+			Backoff:  time.Millisecond,
+		}
+		out, err := CombinedOutput(context.Background(), rr, "vagrant", "up")
+
+		require.NoError(t, err)
+		assert.Equal(t, "done", string(out))
+		assert.Len(t, mock.Calls(), 2)
+	})
+}
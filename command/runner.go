@@ -3,38 +3,155 @@ package command
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
 	"os/exec"
 )
 
 // Runner provides an interface for running external commands.
 type Runner interface {
 	Execute(cmd string, args ...string) ([]byte, error)
+	ExecuteContext(ctx context.Context, cmd string, args ...string) ([]byte, error)
+	ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error
 }
 
 // ShellRunner provides provides a simplified interface to exec.Command making it easier to process output and errors.
 type ShellRunner struct {
 	// Dir is the directory where the commands will be executed.
 	Dir string
+	// Env contains environment variables in "key=value" form that are added to the environment of the current process
+	// when executing commands. Later entries take precedence over earlier ones and over the process environment.
+	Env []string
+	// OverrideEnv executes commands with only the variables in Env instead of merging them into the environment of the
+	// current process.
+	OverrideEnv bool
+	// Stdin is connected to the standard input of the commands. Commands read from the null device when it is nil.
+	Stdin io.Reader
 }
 
 // Execute invokes a shell command with any number of arguments and returns standard output.
 //
 // If the command starts but does not complete successfully, an ExitError will be returned with output from standard
-// error. Any other error will result in a panic.
+// error. A *BinaryNotFoundError is returned when the command cannot be started because the executable is missing or
+// not executable.
 func (r ShellRunner) Execute(cmd string, args ...string) ([]byte, error) {
-	c := exec.Command(cmd, args...)
+	return r.ExecuteContext(context.Background(), cmd, args...)
+}
+
+// ExecuteContext behaves like Execute but kills the command, along with any processes it spawned, when the context is
+// cancelled or its deadline expires. In that case the returned error wraps ctx.Err() instead of being an ExitError.
+func (r ShellRunner) ExecuteContext(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := r.ExecuteStream(ctx, &stdout, nil, cmd, args...)
+
+	return stdout.Bytes(), err
+}
+
+// ExecuteStream behaves like ExecuteContext but writes standard output and standard error to the provided writers as
+// the command produces it instead of buffering it until the command exits. Either writer may be nil to discard that
+// stream. Standard error is still captured for the ExitError returned on failure, up to its last 64 KiB so that
+// long-running commands do not hold on to all of it.
+func (r ShellRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	c := exec.CommandContext(ctx, cmd, args...)
 	c.Dir = r.Dir
+	c.Env = r.environ()
+	c.Stdin = r.Stdin
+	killProcessGroupOnCancel(c)
 
-	var stdout, stderr bytes.Buffer
-	c.Stdout = &stdout
-	c.Stderr = &stderr
+	errBuf := &tailBuffer{max: maxStderr}
+	c.Stdout = stdout
+	c.Stderr = errBuf
+	if stderr != nil {
+		c.Stderr = io.MultiWriter(errBuf, stderr)
+	}
 	err := c.Run()
 
 	if err != nil {
-		if ee, ok := err.(*exec.ExitError); ok {
-			err = newExitError(cmd, ee.ExitCode(), string(stderr.Bytes()))
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%s interrupted: %w", cmd, ctxErr)
+		} else if ee, ok := err.(*exec.ExitError); ok {
+			err = NewExitError(cmd, ee.ExitCode(), errBuf.String())
+		} else {
+			err = startError(c, err)
 		}
 	}
 
-	return stdout.Bytes(), err
+	return err
+}
+
+// ExecuteCombined behaves like ExecuteContext but returns standard output and standard error combined in the order the
+// command wrote them, like exec.Cmd.CombinedOutput. The ExitError returned on failure carries the combined output.
+func (r ShellRunner) ExecuteCombined(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Dir = r.Dir
+	c.Env = r.environ()
+	c.Stdin = r.Stdin
+	killProcessGroupOnCancel(c)
+
+	out, err := c.CombinedOutput()
+
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%s interrupted: %w", cmd, ctxErr)
+		} else if ee, ok := err.(*exec.ExitError); ok {
+			err = NewExitError(cmd, ee.ExitCode(), string(out))
+		} else {
+			err = startError(c, err)
+		}
+	}
+
+	return out, err
+}
+
+// startError converts errors caused by a missing or non-executable binary into a *BinaryNotFoundError. Other errors
+// are returned unchanged.
+func startError(c *exec.Cmd, err error) error {
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return &BinaryNotFoundError{Name: c.Args[0], Err: err}
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && pathErr.Path == c.Path {
+		if _, statErr := os.Stat(c.Path); statErr != nil || errors.Is(err, fs.ErrPermission) {
+			return &BinaryNotFoundError{Name: c.Args[0], Err: err}
+		}
+	}
+	return err
+}
+
+// environ returns the environment for a command, or nil to inherit the environment of the current process.
+func (r ShellRunner) environ() []string {
+	if r.OverrideEnv {
+		return append([]string{}, r.Env...)
+	}
+	if len(r.Env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), r.Env...)
+}
+
+// maxStderr is the amount of standard error ExecuteStream keeps for the ExitError of a failed command.
+const maxStderr = 64 << 10
+
+// tailBuffer is an io.Writer that keeps only the last max bytes written to it.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
 }
@@ -1,8 +1,15 @@
 package command
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,6 +32,28 @@ func TestExecute(t *testing.T) {
 		assert.Equal(t, "/usr\n", string(out))
 	})
 
+	t.Run("with_env", func(t *testing.T) {
+		require.NoError(t, os.Setenv("VAGRANT_EXEC_TEST_PARENT", "parent"))
+		defer os.Unsetenv("VAGRANT_EXEC_TEST_PARENT")
+
+		sr := ShellRunner{Env: []string{"VAGRANT_EXEC_TEST=child"}}
+		out, err := sr.Execute("sh", "-c", "echo $VAGRANT_EXEC_TEST_PARENT $VAGRANT_EXEC_TEST")
+
+		require.NoError(t, err)
+		assert.Equal(t, "parent child\n", string(out))
+	})
+
+	t.Run("override_env", func(t *testing.T) {
+		require.NoError(t, os.Setenv("VAGRANT_EXEC_TEST_PARENT", "parent"))
+		defer os.Unsetenv("VAGRANT_EXEC_TEST_PARENT")
+
+		sr := ShellRunner{Env: []string{"VAGRANT_EXEC_TEST=child"}, OverrideEnv: true}
+		out, err := sr.Execute("/bin/sh", "-c", "echo \"[$VAGRANT_EXEC_TEST_PARENT][$VAGRANT_EXEC_TEST]\"")
+
+		require.NoError(t, err)
+		assert.Equal(t, "[][child]\n", string(out))
+	})
+
 	t.Run("exit_error", func(t *testing.T) {
 		sr := ShellRunner{}
 		_, err := sr.Execute("sh", "-c", "echo 'actual err msg' >&2 && exit 64")
@@ -35,11 +64,155 @@ func TestExecute(t *testing.T) {
 		assert.Equal(t, "sh exited with status 64: actual err msg", ee.Error())
 	})
 
-	t.Run("not_executable", func(t *testing.T) {
+	t.Run("not_found", func(t *testing.T) {
 		sr := ShellRunner{}
 		_, err := sr.Execute("garbage")
 
+		var bnf *BinaryNotFoundError
+		require.True(t, errors.As(err, &bnf))
+		assert.Equal(t, "garbage", bnf.Name)
+		assert.True(t, errors.Is(err, exec.ErrNotFound))
+	})
+
+	t.Run("missing_path", func(t *testing.T) {
+		sr := ShellRunner{}
+		_, err := sr.Execute("/nonexistent/vagrant")
+
+		var bnf *BinaryNotFoundError
+		assert.True(t, errors.As(err, &bnf))
+	})
+
+	t.Run("not_executable", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "vagrant")
+		require.NoError(t, err)
+		f.Close()
+		defer os.Remove(f.Name())
+
+		sr := ShellRunner{}
+		_, err = sr.Execute(f.Name())
+
+		var bnf *BinaryNotFoundError
+		assert.True(t, errors.As(err, &bnf))
+	})
+}
+
+func TestExecuteContext(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sr := ShellRunner{}
+		out, err := sr.ExecuteContext(context.Background(), "echo", "hello world")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello world\n", string(out))
+	})
+
+	t.Run("deadline_exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		sr := ShellRunner{}
+		_, err := sr.ExecuteContext(ctx, "sleep", "10")
+
 		require.Error(t, err)
-		assert.IsType(t, new(exec.Error), err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, "sleep interrupted: context deadline exceeded", err.Error())
 	})
+
+	t.Run("kills_child_processes", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// the orphaned sleep would keep stdout open and block for 10s if only the shell were killed
+		start := time.Now()
+		sr := ShellRunner{}
+		_, err := sr.ExecuteContext(ctx, "sh", "-c", "sleep 10; echo done")
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, time.Since(start) < 5*time.Second, "child process was not killed")
+	})
+}
+
+func TestExecuteStream(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		sr := ShellRunner{}
+		err := sr.ExecuteStream(context.Background(), &stdout, &stderr, "sh", "-c", "echo out && echo err >&2")
+
+		require.NoError(t, err)
+		assert.Equal(t, "out\n", stdout.String())
+		assert.Equal(t, "err\n", stderr.String())
+	})
+
+	t.Run("nil_writers", func(t *testing.T) {
+		sr := ShellRunner{}
+		err := sr.ExecuteStream(context.Background(), nil, nil, "echo", "discarded")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		var stdout bytes.Buffer
+		sr := ShellRunner{Stdin: strings.NewReader("echo from stdin")}
+		err := sr.ExecuteStream(context.Background(), &stdout, nil, "sh")
+
+		require.NoError(t, err)
+		assert.Equal(t, "from stdin\n", stdout.String())
+	})
+
+	t.Run("exit_error", func(t *testing.T) {
+		var stderr bytes.Buffer
+		sr := ShellRunner{}
+		err := sr.ExecuteStream(context.Background(), nil, &stderr, "sh", "-c", "echo 'actual err msg' >&2 && exit 3")
+		require.IsType(t, ExitError{}, err)
+
+		assert.Equal(t, "sh exited with status 3: actual err msg", err.Error())
+		assert.Equal(t, "actual err msg\n", stderr.String())
+	})
+
+	t.Run("long_stderr", func(t *testing.T) {
+		sr := ShellRunner{}
+		err := sr.ExecuteStream(context.Background(), nil, nil, "sh", "-c", "head -c 100000 /dev/zero | tr '\\0' x >&2; echo last >&2; exit 1")
+		require.IsType(t, ExitError{}, err)
+
+		msg := err.(ExitError).Stderr()
+		assert.Len(t, msg, maxStderr)
+		assert.True(t, strings.HasSuffix(msg, "xxxlast\n"))
+	})
+}
+
+func TestExecuteCombined(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sr := ShellRunner{}
+		out, err := sr.ExecuteCombined(context.Background(), "sh", "-c", "echo one && echo two >&2 && echo three")
+
+		require.NoError(t, err)
+		assert.Equal(t, "one\ntwo\nthree\n", string(out))
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		sr := ShellRunner{Stdin: strings.NewReader("echo out && echo err >&2")}
+		out, err := sr.ExecuteCombined(context.Background(), "sh")
+
+		require.NoError(t, err)
+		assert.Equal(t, "out\nerr\n", string(out))
+	})
+
+	t.Run("exit_error", func(t *testing.T) {
+		sr := ShellRunner{}
+		out, err := sr.ExecuteCombined(context.Background(), "sh", "-c", "echo progress && echo 'actual err msg' >&2 && exit 3")
+		require.IsType(t, ExitError{}, err)
+
+		assert.Equal(t, "progress\nactual err msg\n", string(out))
+		assert.Equal(t, 3, err.(ExitError).ExitCode())
+		assert.Equal(t, "progress\nactual err msg\n", err.(ExitError).Stderr())
+	})
+}
+
+func TestNewExitError(t *testing.T) {
+	ee := NewExitError("vagrant", 1, "  something went wrong\n")
+
+	assert.Equal(t, "vagrant exited with status 1: something went wrong", ee.Error())
+	assert.Equal(t, 1, ee.ExitStatus())
+	assert.Equal(t, 1, ee.ExitCode())
+	assert.Equal(t, "  something went wrong\n", ee.Stderr())
 }
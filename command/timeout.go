@@ -0,0 +1,94 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var _ Runner = TimeoutRunner{}
+
+// TimeoutError is returned by a TimeoutRunner when a command runs longer than the runner's Timeout and is killed.
+type TimeoutError struct {
+	// Cmd is the command that timed out.
+	Cmd string
+	// Timeout is the configured timeout.
+	Timeout time.Duration
+	// Err is the underlying error, which wraps context.DeadlineExceeded.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Cmd, e.Timeout)
+}
+
+// Unwrap returns the underlying error.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutRunner wraps another Runner and limits how long each command may run. The deadline is applied through the
+// context passed to the wrapped runner, so a ShellRunner kills the command along with its whole process group when it
+// expires.
+type TimeoutRunner struct {
+	// Runner executes the commands. A ShellRunner is used when it is nil.
+	Runner Runner
+	// Timeout is the maximum duration of a single command. Commands run without a timeout when it is not positive.
+	Timeout time.Duration
+}
+
+// Execute runs a command through the wrapped runner, returning a *TimeoutError if it exceeds the timeout.
+func (r TimeoutRunner) Execute(cmd string, args ...string) ([]byte, error) {
+	return r.ExecuteContext(context.Background(), cmd, args...)
+}
+
+// ExecuteContext is like Execute but includes a context. A deadline on ctx that expires before the timeout is
+// reported as usual rather than as a *TimeoutError.
+func (r TimeoutRunner) ExecuteContext(ctx context.Context, cmd string, args ...string) (out []byte, err error) {
+	err = r.run(ctx, cmd, func(ctx context.Context) (err error) {
+		out, err = r.runner().ExecuteContext(ctx, cmd, args...)
+		return
+	})
+	return
+}
+
+// ExecuteStream is like ExecuteContext but streams output to the provided writers.
+func (r TimeoutRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	return r.run(ctx, cmd, func(ctx context.Context) error {
+		return r.runner().ExecuteStream(ctx, stdout, stderr, cmd, args...)
+	})
+}
+
+// ExecuteCombined is like ExecuteContext but returns standard output and standard error combined. See CombinedOutput.
+func (r TimeoutRunner) ExecuteCombined(ctx context.Context, cmd string, args ...string) (out []byte, err error) {
+	err = r.run(ctx, cmd, func(ctx context.Context) (err error) {
+		out, err = CombinedOutput(ctx, r.runner(), cmd, args...)
+		return
+	})
+	return
+}
+
+// run invokes fn with a context bounded by the timeout and converts deadline errors caused by it.
+func (r TimeoutRunner) run(ctx context.Context, cmd string, fn func(context.Context) error) error {
+	if r.Timeout <= 0 {
+		return fn(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	err := fn(timeoutCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		err = &TimeoutError{Cmd: cmd, Timeout: r.Timeout, Err: err}
+	}
+	return err
+}
+
+func (r TimeoutRunner) runner() Runner {
+	if r.Runner == nil {
+		return ShellRunner{}
+	}
+	return r.Runner
+}
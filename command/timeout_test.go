@@ -0,0 +1,76 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutRunner(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tr := TimeoutRunner{Timeout: 5 * time.Second}
+		out, err := tr.Execute("echo", "hello world")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello world\n", string(out))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		// the orphaned sleep would keep stdout open and block for 10s if only the shell were killed
+		start := time.Now()
+		tr := TimeoutRunner{Runner: ShellRunner{}, Timeout: 100 * time.Millisecond}
+		_, err := tr.Execute("sh", "-c", "sleep 10; echo done")
+
+		var timeoutErr *TimeoutError
+		require.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, "sh", timeoutErr.Cmd)
+		assert.Equal(t, "sh timed out after 100ms", err.Error())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, time.Since(start) < 5*time.Second, "child process was not killed")
+	})
+
+	t.Run("parent_deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		tr := TimeoutRunner{Timeout: 5 * time.Second}
+		_, err := tr.ExecuteContext(ctx, "sleep", "10")
+
+		var timeoutErr *TimeoutError
+		assert.False(t, errors.As(err, &timeoutErr))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var stdout bytes.Buffer
+		tr := TimeoutRunner{Timeout: 100 * time.Millisecond}
+		err := tr.ExecuteStream(context.Background(), &stdout, nil, "sh", "-c", "echo started; sleep 10")
+
+		var timeoutErr *TimeoutError
+		assert.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, "started\n", stdout.String())
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		tr := TimeoutRunner{Timeout: 5 * time.Second}
+		out, err := CombinedOutput(context.Background(), tr, "sh", "-c", "echo one && echo two >&2")
+
+		require.NoError(t, err)
+		assert.Equal(t, "one\ntwo\n", string(out))
+	})
+
+	t.Run("no_timeout", func(t *testing.T) {
+		mock := &MockRunner{}
+		mock.AddResponse(Response{Output: []byte("ok")}, "vagrant", "status")
+
+		out, err := TimeoutRunner{Runner: mock}.Execute("vagrant", "status")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(out))
+		assert.Len(t, mock.Calls(), 1)
+	})
+}
@@ -0,0 +1,83 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+// shareNotFoundPattern matches the errors vagrant connect reports for share names that do not exist or have expired.
+var shareNotFoundPattern = regexp.MustCompile(`(?i)share .*(could ?n.t be found|not found|expired)|unknown share`)
+
+// ConnectOptions configures the behavior of Vagrant.Connect.
+type ConnectOptions struct {
+	// DisableStaticIP connects without assigning a static IP to the shared machine.
+	DisableStaticIP bool
+	// StaticIP is the local IP address to assign to the shared machine. Vagrant picks one when empty.
+	StaticIP string
+	// SSH connects to the shared machine over SSH instead of setting up a network connection.
+	SSH bool
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// ShareNotFoundError is returned by Connect when the share does not exist or has expired.
+type ShareNotFoundError struct {
+	// Name is the share that could not be found.
+	Name string
+	// Err is the underlying command error.
+	Err error
+}
+
+func (e *ShareNotFoundError) Error() string {
+	return fmt.Sprintf("vagrant share %q not found or expired", e.Name)
+}
+
+// Unwrap returns the underlying command error.
+func (e *ShareNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Connect connects to an environment shared with vagrant share and keeps the connection open until the context is
+// cancelled, at which point nil is returned. A *ShareNotFoundError is returned when the share does not exist or has
+// expired.
+func (w wrapper) Connect(ctx context.Context, shareName string, opts ConnectOptions) error {
+	if len(shareName) == 0 {
+		return errors.New("share name cannot be empty")
+	}
+	cmdArgs := []string{"connect"}
+
+	if opts.DisableStaticIP && len(opts.StaticIP) > 0 {
+		return errors.New("StaticIP cannot be used with DisableStaticIP")
+	}
+	if opts.DisableStaticIP {
+		cmdArgs = append(cmdArgs, "--disable-static-ip")
+	}
+	if len(opts.StaticIP) > 0 {
+		if net.ParseIP(opts.StaticIP) == nil {
+			return fmt.Errorf("invalid static IP %q", opts.StaticIP)
+		}
+		cmdArgs = append(cmdArgs, "--static-ip", opts.StaticIP)
+	}
+	if opts.SSH {
+		cmdArgs = append(cmdArgs, "--ssh")
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	cmdArgs = append(cmdArgs, shareName)
+
+	w.logger.Infof("Connecting to vagrant share: %s", shareName)
+	err = w.execUntilCancelled(ctx, cmdArgs...)
+
+	var ee command.ExitError
+	if errors.As(err, &ee) && shareNotFoundPattern.MatchString(ee.Stderr()) {
+		return &ShareNotFoundError{Name: shareName, Err: err}
+	}
+	return err
+}
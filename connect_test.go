@@ -0,0 +1,70 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect(t *testing.T) {
+	t.Run("until_cancelled", func(t *testing.T) {
+		runner := &longRunningRunner{output: []byte("==> connect: Connected to shared machine\n"), stopped: make(chan struct{})}
+		logger := &recordingLogger{}
+		w := wrapper{executable: binary, runner: runner, logger: logger}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- w.Connect(ctx, "bazaar-wolf-4343", ConnectOptions{StaticIP: "172.16.0.10", SSH: true})
+		}()
+
+		cancel()
+		assert.NoError(t, <-errCh)
+		assert.Equal(t, "vagrant connect --static-ip 172.16.0.10 --ssh bazaar-wolf-4343", runner.Calls()[0].String())
+	})
+
+	t.Run("disable_static_ip", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		assert.NoError(t, w.Connect(context.Background(), "bazaar-wolf-4343", ConnectOptions{DisableStaticIP: true}))
+		assert.Equal(t, "vagrant connect --disable-static-ip bazaar-wolf-4343", runner.Calls()[0].String())
+	})
+
+	t.Run("share_not_found", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		stderr := "The share 'bazaar-wolf-4343' could not be found. It may have expired."
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, stderr)}, "vagrant", "connect", "bazaar-wolf-4343")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.Connect(context.Background(), "bazaar-wolf-4343", ConnectOptions{})
+
+		var snf *ShareNotFoundError
+		require.True(t, errors.As(err, &snf))
+		assert.Equal(t, "bazaar-wolf-4343", snf.Name)
+	})
+
+	t.Run("other_error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "connection refused")}, "vagrant", "connect", "bazaar-wolf-4343")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.Connect(context.Background(), "bazaar-wolf-4343", ConnectOptions{})
+
+		var snf *ShareNotFoundError
+		require.Error(t, err)
+		assert.False(t, errors.As(err, &snf))
+	})
+
+	t.Run("invalid_options", func(t *testing.T) {
+		w := wrapper{executable: binary, runner: &command.MockRunner{}, logger: &recordingLogger{}}
+
+		assert.Error(t, w.Connect(context.Background(), "", ConnectOptions{}))
+		assert.Error(t, w.Connect(context.Background(), "share", ConnectOptions{StaticIP: "not-an-ip"}))
+		assert.Error(t, w.Connect(context.Background(), "share", ConnectOptions{StaticIP: "10.0.0.1", DisableStaticIP: true}))
+	})
+}
@@ -0,0 +1,54 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DockerExec runs a command in the container of a machine backed by the docker provider using vagrant docker-exec
+// and returns its output. Unlike SSH it works for containers that do not run an SSH server. You can use an empty
+// string as the machine if you only have one container defined in your Vagrantfile.
+func (w wrapper) DockerExec(machine string, cmd ...string) (string, error) {
+	return w.DockerExecContext(context.Background(), machine, cmd...)
+}
+
+// DockerExecContext is like DockerExec but includes a context.
+func (w wrapper) DockerExecContext(ctx context.Context, machine string, cmd ...string) (string, error) {
+	if len(cmd) == 0 {
+		return "", errors.New("a command is required")
+	}
+	cmdArgs := []string{"docker-exec", "--no-prefix"}
+	if len(machine) > 0 {
+		if err := validateMachineNames([]string{machine}); err != nil {
+			return "", err
+		}
+		cmdArgs = append(cmdArgs, machine)
+	}
+	cmdArgs = append(cmdArgs, "--")
+	cmdArgs = append(cmdArgs, cmd...)
+
+	out, err := w.exec(ctx, cmdArgs...)
+	return string(out), err
+}
+
+// dockerSSHError adds a hint to use DockerExec to an SSH error if the machine is backed by the docker provider,
+// whose containers usually do not run an SSH server. The provider is only looked up for an *SSHNotReadyError, so that
+// a remote command exiting with an error does not cost an extra status call. Other errors are returned unchanged.
+func (w wrapper) dockerSSHError(ctx context.Context, machine string, err error) error {
+	var notReady *SSHNotReadyError
+	if !errors.As(err, &notReady) || ctx.Err() != nil {
+		return err
+	}
+
+	statuses, _ := w.StatusContext(ctx)
+	for _, status := range statuses {
+		if (len(machine) == 0 && len(statuses) == 1) || status.Name == machine {
+			if status.Provider == "docker" {
+				return fmt.Errorf("machine %s uses the docker provider, which may not support ssh, use DockerExec "+
+					"instead: %w", status.Name, err)
+			}
+		}
+	}
+	return err
+}
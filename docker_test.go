@@ -0,0 +1,83 @@
+package vagrantexec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerExec(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"docker-exec", "--no-prefix", "app", "--", "cat", "/etc/hostname"})([]byte("4f1c2a\n"), nil)
+
+		out, err := w.DockerExec("app", "cat", "/etc/hostname")
+		require.NoError(t, err)
+		assert.Equal(t, "4f1c2a\n", out)
+	})
+
+	t.Run("single_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"docker-exec", "--no-prefix", "--", "uptime"})(nil, nil)
+
+		_, err := w.DockerExec("", "uptime")
+		assert.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"docker-exec", "--no-prefix", "--", "uptime"})(nil, errors.New("runner error"))
+
+		_, err := w.DockerExec("", "uptime")
+		assert.Error(t, err)
+	})
+
+	t.Run("no_command", func(t *testing.T) {
+		w := mockedWrapperFn(nil)(nil, nil)
+
+		_, err := w.DockerExec("app")
+		assert.Error(t, err)
+	})
+}
+
+func TestSSHDockerProvider(t *testing.T) {
+	sshArgs := []string{"ssh", "--no-tty", "--command", "uptime", "app"}
+	statusArgs := []string{"status", "--machine-readable", "--no-color"}
+	sshErr := command.NewExitError("vagrant", 1, "The provider for this Vagrant-managed machine is reporting that it\nis not yet ready for SSH.")
+
+	t.Run("docker", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: sshErr}, "vagrant", sshArgs...)
+		runner.AddResponse(command.Response{Output: []byte("1562175814,app,provider-name,docker\n1562175814,app,state,running\n")}, "vagrant", statusArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		_, err := w.SSH("app", "uptime")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "machine app uses the docker provider, which may not support ssh, use DockerExec instead")
+		assert.True(t, errors.Is(err, sshErr))
+	})
+
+	t.Run("virtualbox", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: sshErr}, "vagrant", sshArgs...)
+		runner.AddResponse(command.Response{Output: []byte("1562175814,app,provider-name,virtualbox\n1562175814,app,state,running\n")}, "vagrant", statusArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		_, err := w.SSH("app", "uptime")
+		var notReady *SSHNotReadyError
+		require.True(t, errors.As(err, &notReady))
+		assert.Equal(t, sshErr, notReady.Err)
+		assert.NotContains(t, err.Error(), "DockerExec")
+	})
+
+	t.Run("remote_command_failed", func(t *testing.T) {
+		grepErr := command.NewExitError("vagrant", 1, "")
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: grepErr}, "vagrant", "ssh", "--no-tty", "--command", "grep foo /etc/hosts", "app")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		_, err := w.SSH("app", "grep foo /etc/hosts")
+		assert.Equal(t, grepErr, err)
+		assert.Len(t, runner.Calls(), 1)
+	})
+}
@@ -0,0 +1,141 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scpBinary is the OpenSSH copy tool used to download files from a guest.
+const scpBinary = "scp"
+
+// DownloadOptions configures the behavior of Vagrant.Download.
+type DownloadOptions struct {
+	// Machine is the machine to download from. It can be empty if you only have one VM defined.
+	Machine string
+	// Recursive copies a directory and its contents. It is not supported together with Cat.
+	Recursive bool
+	// Cat reads the file with vagrant ssh -c 'cat <file>' instead of scp, which works without scp on the host but holds
+	// the whole file in memory and is therefore only suited for small files.
+	Cat bool
+}
+
+// Download copies a file or directory from a guest machine to the host. Vagrant has no download command, so the file is
+// copied with scp using the connection details reported by vagrant ssh-config, which requires scp to be installed on
+// the host. Relative host paths are resolved against the Vagrantfile directory. When the host path is an existing
+// directory the file is copied into it, otherwise the parent directory must exist. Either directory must be writable.
+func (w wrapper) Download(guestPath, hostPath string, opts DownloadOptions) error {
+	return w.DownloadContext(context.Background(), guestPath, hostPath, opts)
+}
+
+// DownloadContext is like Download but includes a context.
+func (w wrapper) DownloadContext(ctx context.Context, guestPath, hostPath string, opts DownloadOptions) error {
+	if len(guestPath) == 0 {
+		return errors.New("download source cannot be empty")
+	}
+	if len(hostPath) == 0 {
+		return errors.New("download destination cannot be empty")
+	}
+	if opts.Cat && opts.Recursive {
+		return errors.New("recursive downloads are not supported with cat")
+	}
+	if len(opts.Machine) > 0 {
+		if err := validateMachineNames([]string{opts.Machine}); err != nil {
+			return err
+		}
+	}
+
+	localPath := hostPath
+	if !filepath.IsAbs(localPath) {
+		localPath = filepath.Join(w.dir, localPath)
+	}
+	isDir := false
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		isDir = true
+	}
+	destDir := localPath
+	if !isDir {
+		destDir = filepath.Dir(localPath)
+	}
+	if err := checkWritableDir(destDir); err != nil {
+		return fmt.Errorf("invalid download destination: %w", err)
+	}
+
+	if w.dryRun {
+		w.logger.Infof("Dry run, not downloading %s from vagrant machine", guestPath)
+		return nil
+	}
+
+	w.logger.Infof("Downloading %s from vagrant machine", guestPath)
+	if opts.Cat {
+		if isDir {
+			localPath = filepath.Join(localPath, path.Base(guestPath))
+		}
+		return w.downloadCat(ctx, guestPath, localPath, opts.Machine)
+	}
+
+	info, err := w.SSHConfigContext(ctx, opts.Machine)
+	if err != nil {
+		return fmt.Errorf("ssh is not available: %w", err)
+	}
+	_, err = w.execTool(ctx, scpBinary, scpArgs(info, guestPath, hostPath, opts.Recursive)...)
+	if err != nil {
+		return fmt.Errorf("cannot download %s: %w", guestPath, err)
+	}
+	return nil
+}
+
+// downloadCat writes the output of cat on the guest to localPath. Only standard output is captured, even when the
+// wrapper combines it with standard error, so that messages from vagrant or ssh do not end up in the file.
+func (w wrapper) downloadCat(ctx context.Context, guestPath, localPath, machine string) error {
+	w.combined = false
+	out, err := w.SSHWithOptionsContext(ctx, "cat "+shellQuote(guestPath), SSHOptions{Machine: machine})
+	if err != nil {
+		return fmt.Errorf("cannot download %s: %w", guestPath, err)
+	}
+	return os.WriteFile(localPath, []byte(out), 0644)
+}
+
+// scpArgs builds the scp arguments that copy guestPath from the machine described by info to hostPath. Every option of
+// the ssh-config is passed on, so that e.g. host key checking is disabled just like for vagrant ssh.
+func scpArgs(info *SSHInfo, guestPath, hostPath string, recursive bool) []string {
+	args := []string{"-q"}
+	if recursive {
+		args = append(args, "-r")
+	}
+	if info.Port > 0 {
+		args = append(args, "-P", strconv.Itoa(info.Port))
+	}
+	if len(info.IdentityFile) > 0 {
+		args = append(args, "-i", info.IdentityFile)
+	}
+
+	keys := make([]string, 0, len(info.Options))
+	for key := range info.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-o", key+"="+info.Options[key])
+	}
+
+	host := info.HostName
+	if len(host) == 0 {
+		host = info.Host
+	}
+	if len(info.User) > 0 {
+		host = info.User + "@" + host
+	}
+	return append(args, host+":"+guestPath, hostPath)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
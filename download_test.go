@@ -0,0 +1,117 @@
+package vagrantexec
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stderrRunner appends stderr to the output of commands whose output is combined.
+type stderrRunner struct {
+	*command.MockRunner
+	stderr string
+}
+
+func (r *stderrRunner) ExecuteCombined(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	out, err := r.ExecuteContext(ctx, cmd, args...)
+	return append(out, r.stderr...), err
+}
+
+func TestDownload(t *testing.T) {
+	newDownload := func(t *testing.T) (wrapper, *command.MockRunner, string) {
+		sshConfig, err := ioutil.ReadFile("testdata/ssh-config")
+		require.NoError(t, err)
+		dir, err := ioutil.TempDir("", "vagrant-exec-download")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: sshConfig}, "vagrant", "ssh-config", "--no-color")
+		return wrapper{executable: binary, dir: "testdata/env", runner: runner, logger: &recordingLogger{}}, runner, dir
+	}
+
+	t.Run("scp", func(t *testing.T) {
+		w, runner, dir := newDownload(t)
+
+		require.NoError(t, w.Download("/var/log/syslog", dir, DownloadOptions{Recursive: true}))
+		calls := runner.Calls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "scp -q -r -P 2222 -i /home/user/project/.vagrant/machines/default/virtualbox/private_key "+
+			"-o IdentitiesOnly=yes -o LogLevel=FATAL -o PasswordAuthentication=no -o StrictHostKeyChecking=no "+
+			"-o UserKnownHostsFile=/dev/null vagrant@127.0.0.1:/var/log/syslog "+dir, calls[1].String())
+	})
+
+	t.Run("scp_error", func(t *testing.T) {
+		w, runner, dir := newDownload(t)
+		sshConfig, err := ioutil.ReadFile("testdata/ssh-config")
+		require.NoError(t, err)
+		hosts, err := parseSSHConfig(sshConfig)
+		require.NoError(t, err)
+		runner.AddResponse(command.Response{Err: command.NewExitError("scp", 1, "No such file or directory")},
+			"scp", scpArgs(&hosts[0], "/missing", dir, false)...)
+
+		err = w.Download("/missing", dir, DownloadOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot download /missing")
+	})
+
+	t.Run("cat", func(t *testing.T) {
+		w, runner, dir := newDownload(t)
+		runner.AddResponse(command.Response{Output: []byte("hello\n")},
+			"vagrant", "ssh", "--no-tty", "--command", `cat '/tmp/it'\''s.txt'`, "web")
+
+		require.NoError(t, w.Download("/tmp/it's.txt", dir, DownloadOptions{Machine: "web", Cat: true}))
+		content, err := ioutil.ReadFile(filepath.Join(dir, "it's.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", string(content))
+	})
+
+	t.Run("cat_combined_output", func(t *testing.T) {
+		w, runner, dir := newDownload(t)
+		runner.AddResponse(command.Response{Output: []byte("hello\n")},
+			"vagrant", "ssh", "--no-tty", "--command", "cat '/tmp/file.txt'")
+		w.runner = &stderrRunner{MockRunner: runner, stderr: "Connection to 127.0.0.1 closed.\n"}
+		w.combined = true
+
+		require.NoError(t, w.Download("/tmp/file.txt", dir, DownloadOptions{Cat: true}))
+		content, err := os.ReadFile(filepath.Join(dir, "file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", string(content))
+	})
+
+	t.Run("scp_hooks", func(t *testing.T) {
+		w, _, dir := newDownload(t)
+		var commands []string
+		WithExecHook(func(cmd string, args []string, dur time.Duration, err error) {
+			commands = append(commands, cmd)
+		})(&w)
+
+		require.NoError(t, w.Download("/var/log/syslog", dir, DownloadOptions{}))
+		assert.Equal(t, []string{"vagrant", "scp"}, commands)
+	})
+
+	t.Run("invalid_destination", func(t *testing.T) {
+		w, runner, dir := newDownload(t)
+
+		err := w.Download("/var/log/syslog", filepath.Join(dir, "missing", "syslog"), DownloadOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid download destination")
+		assert.Empty(t, runner.Calls())
+	})
+
+	t.Run("invalid_options", func(t *testing.T) {
+		w, _, dir := newDownload(t)
+
+		assert.EqualError(t, w.Download("", dir, DownloadOptions{}), "download source cannot be empty")
+		assert.EqualError(t, w.Download("/tmp", "", DownloadOptions{}), "download destination cannot be empty")
+		assert.Error(t, w.Download("/tmp", dir, DownloadOptions{Cat: true, Recursive: true}))
+		assert.Error(t, w.Download("/tmp", dir, DownloadOptions{Machine: "web&"}))
+	})
+}
@@ -0,0 +1,90 @@
+package vagrantexec
+
+import (
+	"context"
+	"fmt"
+)
+
+// EnsureUpResult describes what Vagrant.EnsureUp did to each machine.
+type EnsureUpResult struct {
+	// Resumed lists the machines that were suspended or paused and have been resumed.
+	Resumed []string
+	// Booted lists the machines that were not created or powered off and have been brought up.
+	Booted []string
+	// Unchanged lists the machines that were already running.
+	Unchanged []string
+}
+
+// EnsureUp brings machines into the running state whatever state they are in. Suspended and paused machines are
+// resumed, which restores their memory instead of booting them again, machines that are not created, powered off or
+// aborted are brought up with opts, and running machines are left alone. Machines that are in the middle of saving or
+// stopping, or in a state vagrant cannot recover from on its own, are reported as an error without running any
+// command, since neither resume nor up is safe for them. Only the machines in opts.Machines are considered when it is
+// not empty.
+func (w wrapper) EnsureUp(opts UpOptions) (*EnsureUpResult, error) {
+	return w.EnsureUpContext(context.Background(), opts)
+}
+
+// EnsureUpContext is like EnsureUp but includes a context. The machines handled before a failure are reported along
+// with the error.
+func (w wrapper) EnsureUpContext(ctx context.Context, opts UpOptions) (*EnsureUpResult, error) {
+	if _, err := upArgs(opts); err != nil {
+		return nil, err
+	}
+
+	statuses, err := w.StatusContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Machines) > 0 {
+		var selected []MachineStatus
+		for _, name := range opts.Machines {
+			status, ok := findStatus(statuses, name)
+			if !ok {
+				return nil, fmt.Errorf("machine %s is not defined in the Vagrantfile", name)
+			}
+			selected = append(selected, status)
+		}
+		statuses = selected
+	}
+
+	result := &EnsureUpResult{}
+	var toResume, toBoot []string
+	for _, status := range statuses {
+		switch status.State {
+		case Running:
+			result.Unchanged = append(result.Unchanged, status.Name)
+		case Saved, Paused:
+			toResume = append(toResume, status.Name)
+		case NotCreated, PowerOff, Aborted:
+			toBoot = append(toBoot, status.Name)
+		default:
+			return nil, fmt.Errorf("machine %s cannot be brought up while it is %s", status.Name, status.State)
+		}
+	}
+
+	if len(toResume) > 0 {
+		if err := w.ResumeContext(ctx, toResume...); err != nil {
+			return result, err
+		}
+		result.Resumed = toResume
+	}
+	if len(toBoot) > 0 {
+		opts.Machines = toBoot
+		if err := w.UpWithOptionsContext(ctx, opts); err != nil {
+			return result, err
+		}
+		result.Booted = toBoot
+	}
+	return result, nil
+}
+
+// findStatus returns the status of the named machine.
+func findStatus(statuses []MachineStatus, name string) (MachineStatus, bool) {
+	for _, status := range statuses {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return MachineStatus{}, false
+}
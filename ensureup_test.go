@@ -0,0 +1,81 @@
+package vagrantexec
+
+import (
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureUp(t *testing.T) {
+	statusArgs := []string{"status", "--machine-readable", "--no-color"}
+	newEnsureUp := func(status string) (wrapper, *command.MockRunner) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: []byte(status)}, "vagrant", statusArgs...)
+		return wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}, runner
+	}
+
+	t.Run("mixed_states", func(t *testing.T) {
+		w, runner := newEnsureUp("1562175814,web,state,running\n1562175814,db,state,saved\n" +
+			"1562175814,cache,state,not_created\n1562175814,worker,state,poweroff\n1562175814,queue,state,paused\n")
+
+		result, err := w.EnsureUp(UpOptions{Provider: "virtualbox"})
+		require.NoError(t, err)
+		assert.Equal(t, &EnsureUpResult{
+			Resumed:   []string{"db", "queue"},
+			Booted:    []string{"cache", "worker"},
+			Unchanged: []string{"web"},
+		}, result)
+
+		calls := runner.Calls()
+		require.Len(t, calls, 3)
+		assert.Equal(t, "vagrant resume db queue", calls[1].String())
+		assert.Equal(t, "vagrant up --provider virtualbox cache worker", calls[2].String())
+	})
+
+	t.Run("already_running", func(t *testing.T) {
+		w, runner := newEnsureUp("1562175814,web,state,running\n")
+
+		result, err := w.EnsureUp(UpOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"web"}, result.Unchanged)
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("selected_machines", func(t *testing.T) {
+		w, runner := newEnsureUp("1562175814,web,state,saved\n1562175814,db,state,saved\n")
+
+		result, err := w.EnsureUp(UpOptions{Machines: []string{"db"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db"}, result.Resumed)
+		require.Len(t, runner.Calls(), 2)
+		assert.Equal(t, "vagrant resume db", runner.Calls()[1].String())
+	})
+
+	t.Run("undefined_machine", func(t *testing.T) {
+		w, _ := newEnsureUp("1562175814,web,state,running\n")
+
+		_, err := w.EnsureUp(UpOptions{Machines: []string{"db"}})
+		assert.EqualError(t, err, "machine db is not defined in the Vagrantfile")
+	})
+
+	t.Run("transitional_state", func(t *testing.T) {
+		w, runner := newEnsureUp("1562175814,web,state,saving\n1562175814,db,state,not_created\n")
+
+		_, err := w.EnsureUp(UpOptions{})
+		assert.EqualError(t, err, "machine web cannot be brought up while it is saving")
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("resume_error", func(t *testing.T) {
+		w, runner := newEnsureUp("1562175814,web,state,saved\n1562175814,db,state,not_created\n")
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "resume failed")}, "vagrant", "resume", "web")
+
+		result, err := w.EnsureUp(UpOptions{})
+		require.Error(t, err)
+		assert.Empty(t, result.Resumed)
+		assert.Empty(t, result.Booted)
+		assert.Len(t, runner.Calls(), 2)
+	})
+}
@@ -0,0 +1,40 @@
+package vagrantexec
+
+import "github.com/dominodatalab/vagrant-exec/command"
+
+// Environment returns a Vagrant bound to the Vagrantfile in dir, or one of its parents, that shares every other option
+// of the receiver, such as the logger, environment variables, timeouts and hooks. It is cheaper than calling New for
+// every environment managed by a process. A Vagrantfile configured with WithVagrantfile is not carried over.
+//
+// When a custom runner was configured with WithRunner it is used as is, so it is responsible for running commands in
+// the right directory.
+func (w wrapper) Environment(dir string) Vagrant {
+	if len(dir) == 0 {
+		panic("vagrantfile dir cannot be empty")
+	}
+
+	return w.rebind(dir)
+}
+
+// rebind returns a copy of w that runs commands in dir without the Vagrantfile configured with WithVagrantfile. An
+// empty dir leaves the copy bound to no environment at all, with commands run in the working directory of the process.
+func (w wrapper) rebind(dir string) wrapper {
+	w.dir = dir
+	if len(w.vagrantfile) > 0 {
+		w.vagrantfile = ""
+		env := map[string]string{}
+		for k, v := range w.env {
+			if k != "VAGRANT_CWD" && k != "VAGRANT_VAGRANTFILE" {
+				env[k] = v
+			}
+		}
+		w.env = env
+	}
+
+	if sr, ok := w.runner.(command.ShellRunner); ok {
+		sr.Dir = w.dir
+		sr.Env = w.environ()
+		w.runner = sr
+	}
+	return w
+}
@@ -0,0 +1,54 @@
+package vagrantexec
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironment(t *testing.T) {
+	t.Run("shell_runner", func(t *testing.T) {
+		parent := New("/some/path", false, WithEnv(map[string]string{"VAGRANT_LOG": "debug"}))
+
+		env := parent.Environment("testdata/env").(wrapper)
+		assert.Equal(t, "testdata/env", env.dir)
+
+		r := env.runner.(command.ShellRunner)
+		assert.Equal(t, "testdata/env", r.Dir)
+		assert.Equal(t, []string{"VAGRANT_LOG=debug"}, r.Env)
+
+		assert.Equal(t, "/some/path", parent.(wrapper).dir)
+		assert.Equal(t, "/some/path", parent.(wrapper).runner.(command.ShellRunner).Dir)
+	})
+
+	t.Run("vagrantfile_dir", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		parent := New("/some/path", false, WithRunner(runner), WithLogger(&recordingLogger{}))
+
+		require.NoError(t, parent.Environment("testdata/env/nested").Up())
+		assert.Error(t, parent.Environment("testdata/does-not-exist").Up())
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("drops_vagrantfile", func(t *testing.T) {
+		parent := New(".", false,
+			WithVagrantfile("testdata/env/Vagrantfile"),
+			WithEnv(map[string]string{"VAGRANT_LOG": "debug"}),
+		)
+
+		env := parent.Environment("testdata/env/nested").(wrapper)
+		assert.Empty(t, env.vagrantfile)
+		assert.Equal(t, []string{"VAGRANT_LOG=debug"}, env.runner.(command.ShellRunner).Env)
+
+		abs, err := filepath.Abs("testdata/env")
+		require.NoError(t, err)
+		assert.Equal(t, abs, parent.(wrapper).env["VAGRANT_CWD"])
+	})
+
+	t.Run("empty_dir", func(t *testing.T) {
+		assert.Panics(t, func() { New(".", false).Environment("") })
+	})
+}
@@ -0,0 +1,153 @@
+package vagrantexec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+// ErrorKind classifies common Vagrant failure modes.
+type ErrorKind int
+
+const (
+	// UnknownError is any failure that could not be classified.
+	UnknownError ErrorKind = iota
+	// MachineNotCreated means the command requires a machine that has not been created yet.
+	MachineNotCreated
+	// VagrantfileNotFound means no Vagrantfile could be found for the environment.
+	VagrantfileNotFound
+	// BoxNotFound means the requested box does not exist locally or in the remote catalog.
+	BoxNotFound
+	// ProviderNotAvailable means the requested provider is not installed or not usable on this host.
+	ProviderNotAvailable
+	// Transient means the command failed because of a temporary condition, like a network error or a timeout, and
+	// may succeed if it is run again.
+	Transient
+	// ProviderUnreachable means the provider is installed but its daemon or API, like libvirtd or the Docker daemon,
+	// cannot be reached, so the state of the machines is unknown.
+	ProviderUnreachable
+)
+
+// errorPatterns maps error output produced by vagrant to the kind of failure it represents.
+var errorPatterns = []struct {
+	kind    ErrorKind
+	pattern *regexp.Regexp
+}{
+	{MachineNotCreated, regexp.MustCompile(`(?i)must be created before|machine is not created`)},
+	{VagrantfileNotFound, regexp.MustCompile(`(?i)Vagrant environment or target machine is required`)},
+	{BoxNotFound, regexp.MustCompile(`(?i)box .* could not be found`)},
+	{ProviderNotAvailable, regexp.MustCompile(`(?i)isn't usable on this system|no usable default provider|provider .* could not be found`)},
+	{ProviderUnreachable, regexp.MustCompile(`(?i)error while connecting to libvirt|failed to connect socket to .*libvirt|cannot connect to the docker daemon|failed to create the virtualbox object`)},
+	{Transient, regexp.MustCompile(`(?i)error occurred while downloading|could not resolve host|temporary failure in name resolution|connection (timed out|refused|reset)|operation timed out|timed out while waiting`)},
+}
+
+// Error is returned when a vagrant command fails in a recognized way. The underlying error, usually a
+// command.ExitError carrying the raw stderr output and exit code, is available through errors.Unwrap or errors.As.
+// Unrecognized failures are returned as the command.ExitError itself.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsMachineNotCreated returns true if the error was caused by a machine that has not been created.
+func IsMachineNotCreated(err error) bool {
+	return kindOf(err) == MachineNotCreated
+}
+
+// IsVagrantfileNotFound returns true if the error was caused by a missing Vagrantfile.
+func IsVagrantfileNotFound(err error) bool {
+	return kindOf(err) == VagrantfileNotFound
+}
+
+// IsBoxNotFound returns true if the error was caused by a box that could not be found.
+func IsBoxNotFound(err error) bool {
+	return kindOf(err) == BoxNotFound
+}
+
+// IsProviderNotAvailable returns true if the error was caused by a provider that is missing or unusable.
+func IsProviderNotAvailable(err error) bool {
+	return kindOf(err) == ProviderNotAvailable
+}
+
+// IsTransient returns true if the error was caused by a temporary condition and the command may be retried.
+func IsTransient(err error) bool {
+	return kindOf(err) == Transient
+}
+
+// IsProviderUnreachable returns true if the error was caused by a provider daemon or API that could not be reached.
+func IsProviderUnreachable(err error) bool {
+	return kindOf(err) == ProviderUnreachable
+}
+
+// kindOf extracts the ErrorKind from an error chain.
+func kindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return UnknownError
+}
+
+// classifyError wraps command exit errors whose stderr output matches a known failure mode in an *Error. All other
+// errors are returned unchanged.
+func classifyError(err error) error {
+	var ee command.ExitError
+	if !errors.As(err, &ee) {
+		return err
+	}
+
+	for _, ep := range errorPatterns {
+		if ep.pattern.MatchString(ee.Stderr()) {
+			return &Error{Kind: ep.kind, Err: err}
+		}
+	}
+	return err
+}
+
+// sshNotReadyPattern matches the errors vagrant and ssh print when the guest does not accept SSH connections yet, e.g.
+// right after the machine was booted or while the provider is still assigning it an IP address.
+var sshNotReadyPattern = regexp.MustCompile(`(?i)not yet ready for ssh|(ssh|kex)_exchange_identification|` +
+	`ssh: connect to host .*(connection refused|connection timed out|no route to host)|` +
+	`communicator could not be established`)
+
+// SSHNotReadyError is returned by the methods that run commands over SSH when the guest does not accept SSH
+// connections yet. The command can be retried once the guest has finished booting.
+type SSHNotReadyError struct {
+	// Machine is the machine the command was run on. It is empty when no machine was named.
+	Machine string
+	// Err is the underlying command error.
+	Err error
+}
+
+func (e *SSHNotReadyError) Error() string {
+	if len(e.Machine) == 0 {
+		return fmt.Sprintf("machine is not ready for ssh: %v", e.Err)
+	}
+	return fmt.Sprintf("machine %s is not ready for ssh: %v", e.Machine, e.Err)
+}
+
+// Unwrap returns the underlying command error.
+func (e *SSHNotReadyError) Unwrap() error {
+	return e.Err
+}
+
+// sshNotReadyError wraps the error of an SSH command in an *SSHNotReadyError when its output shows that the guest does
+// not accept SSH connections yet. Other errors are returned unchanged.
+func sshNotReadyError(machine string, err error) error {
+	var ee command.ExitError
+	if errors.As(err, &ee) && sshNotReadyPattern.MatchString(ee.Stderr()) {
+		return &SSHNotReadyError{Machine: machine, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package vagrantexec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	testcases := []struct {
+		name   string
+		stderr string
+		kind   ErrorKind
+	}{
+		{
+			"machine_not_created",
+			"VM must be created before running this command. Run `vagrant up` first.",
+			MachineNotCreated,
+		},
+		{
+			"vagrantfile_not_found",
+			"A Vagrant environment or target machine is required to run this\ncommand. Run `vagrant init` to create a new Vagrant environment.",
+			VagrantfileNotFound,
+		},
+		{
+			"box_not_found",
+			"The box 'nope/nope' could not be found or\ncould not be accessed in the remote catalog.",
+			BoxNotFound,
+		},
+		{
+			"provider_not_usable",
+			"The provider 'virtualbox' that was requested to back the machine\n'default' is reporting that it isn't usable on this system.",
+			ProviderNotAvailable,
+		},
+		{
+			"provider_not_installed",
+			"The provider 'libvirt' could not be found, but was requested to\nback the machine 'default'.",
+			ProviderNotAvailable,
+		},
+		{
+			"no_default_provider",
+			"No usable default provider could be found for your system.",
+			ProviderNotAvailable,
+		},
+		{
+			"libvirt_unreachable",
+			"Error while connecting to Libvirt: Error making a connection to libvirt URI qemu:///system?no_verify=1:\nCall to virConnectOpen failed: Failed to connect socket to '/var/run/libvirt/libvirt-sock': Connection refused",
+			ProviderUnreachable,
+		},
+		{
+			"docker_unreachable",
+			"There was an error executing the following command with Docker:\n\nCommand: [\"docker\", \"ps\", \"-a\", \"-q\", \"--no-trunc\"]\n\nStderr: Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?",
+			ProviderUnreachable,
+		},
+		{
+			"virtualbox_unreachable",
+			"VBoxManage: error: Failed to create the VirtualBox object!\nVBoxManage: error: The object is not ready",
+			ProviderUnreachable,
+		},
+		{
+			"box_download",
+			"An error occurred while downloading the remote file. The error\nmessage, if any, is reproduced below.\n\nCould not resolve host: vagrantcloud.com",
+			Transient,
+		},
+		{
+			"boot_timeout",
+			"Timed out while waiting for the machine to boot. This means that\nVagrant was unable to communicate with the guest machine.",
+			Transient,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ee := command.NewExitError("vagrant", 1, tc.stderr)
+			err := classifyError(ee)
+
+			var e *Error
+			require.True(t, errors.As(err, &e))
+			assert.Equal(t, tc.kind, e.Kind)
+			assert.Equal(t, ee, errors.Unwrap(err))
+			assert.Equal(t, ee.Error(), err.Error())
+		})
+	}
+
+	t.Run("unclassified", func(t *testing.T) {
+		ee := command.NewExitError("vagrant", 1, "something else went wrong")
+		assert.Equal(t, ee, classifyError(ee))
+	})
+
+	t.Run("not_exit_error", func(t *testing.T) {
+		err := errors.New("runner error")
+		assert.Equal(t, err, classifyError(err))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		assert.NoError(t, classifyError(nil))
+	})
+}
+
+func TestErrorHelpers(t *testing.T) {
+	newErr := func(kind ErrorKind) error {
+		return &Error{Kind: kind, Err: errors.New("underlying")}
+	}
+
+	assert.True(t, IsMachineNotCreated(newErr(MachineNotCreated)))
+	assert.True(t, IsVagrantfileNotFound(newErr(VagrantfileNotFound)))
+	assert.True(t, IsBoxNotFound(newErr(BoxNotFound)))
+	assert.True(t, IsProviderNotAvailable(newErr(ProviderNotAvailable)))
+	assert.True(t, IsTransient(newErr(Transient)))
+	assert.True(t, IsProviderUnreachable(newErr(ProviderUnreachable)))
+
+	assert.False(t, IsMachineNotCreated(newErr(BoxNotFound)))
+	assert.False(t, IsMachineNotCreated(errors.New("plain error")))
+	assert.False(t, IsMachineNotCreated(nil))
+}
+
+func TestExecClassifiesErrors(t *testing.T) {
+	stderr := "VM must be created before running this command. Run `vagrant up` first."
+	w := mockedWrapperFn([]string{"ssh", "--no-tty", "--command", "uptime"})(nil, command.NewExitError("vagrant", 1, stderr))
+
+	_, err := w.SSH("", "uptime")
+	assert.True(t, IsMachineNotCreated(err))
+}
+
+func TestExitCode(t *testing.T) {
+	t.Run("classified", func(t *testing.T) {
+		stderr := "The box 'ubuntu/focal64' could not be found."
+		w := mockedWrapperFn([]string{"up"})(nil, command.NewExitError("vagrant", 1, stderr))
+
+		err := w.Up()
+		require.True(t, IsBoxNotFound(err))
+
+		var ee command.ExitError
+		require.True(t, errors.As(err, &ee))
+		assert.Equal(t, 1, ee.ExitCode())
+	})
+
+	t.Run("unclassified", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh", "--no-tty", "--command", "false"})(nil, command.NewExitError("vagrant", 2, ""))
+
+		_, err := w.SSH("", "false")
+
+		var ee command.ExitError
+		require.True(t, errors.As(err, &ee))
+		assert.Equal(t, 2, ee.ExitCode())
+	})
+}
+
+func TestSSHNotReadyError(t *testing.T) {
+	testcases := []struct {
+		name   string
+		stderr string
+	}{
+		{
+			name: "virtualbox",
+			stderr: "kex_exchange_identification: read: Connection reset by peer\r\n" +
+				"Connection reset by 127.0.0.1 port 2222\r\n",
+		},
+		{
+			name:   "virtualbox_refused",
+			stderr: "ssh: connect to host 127.0.0.1 port 2222: Connection refused\r\n",
+		},
+		{
+			name: "libvirt",
+			stderr: "The provider for this Vagrant-managed machine is reporting that it\n" +
+				"is not yet ready for SSH. Depending on your provider this can carry\n" +
+				"different meanings. Make sure your machine is created and running and\n" +
+				"try again. Additionally, check the output of `vagrant status` to verify\n" +
+				"that the machine is in the state that you expect. If you continue to\n" +
+				"get this error message, please view the documentation for the provider\n" +
+				"you're using.\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmdErr := command.NewExitError("vagrant", 255, tc.stderr)
+			runner := &command.MockRunner{}
+			runner.AddResponse(command.Response{Err: cmdErr}, "vagrant", "ssh", "--no-tty", "--command", "uptime", "web")
+			w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+			_, err := w.SSH("web", "uptime")
+			var notReady *SSHNotReadyError
+			require.True(t, errors.As(err, &notReady))
+			assert.Equal(t, "web", notReady.Machine)
+			assert.True(t, errors.Is(err, cmdErr))
+			assert.Contains(t, err.Error(), "machine web is not ready for ssh")
+		})
+	}
+
+	t.Run("remote_command_failed", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "cat: /missing: No such file or directory")},
+			"vagrant", "ssh", "--no-tty", "--command", "cat /missing")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		_, err := w.SSH("", "cat /missing")
+		var notReady *SSHNotReadyError
+		assert.False(t, errors.As(err, &notReady))
+	})
+}
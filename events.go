@@ -0,0 +1,143 @@
+package vagrantexec
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OutputEvent is a line of output that does not match any other event type.
+	OutputEvent ProvisionEventType = iota
+	// ActionStartedEvent means vagrant started an action, such as up or provision, on a machine.
+	ActionStartedEvent
+	// ActionFinishedEvent means vagrant finished an action on a machine.
+	ActionFinishedEvent
+	// BoxDownloadEvent reports the progress of a box download in Progress.
+	BoxDownloadEvent
+	// MachineBootedEvent means the machine booted and is reachable over SSH.
+	MachineBootedEvent
+	// ProvisionerStartedEvent means vagrant started running the provisioner named in Provisioner.
+	ProvisionerStartedEvent
+	// DoneEvent is the last event sent before the channel is closed. Err is set if the command failed.
+	DoneEvent
+)
+
+var (
+	boxDownloadProgress = regexp.MustCompile(`Progress: (\d+)%`)
+	provisionerStarted  = regexp.MustCompile(`Running provisioner: (.+?)\.\.\.$`)
+	machineBooted       = regexp.MustCompile(`Machine booted and ready!`)
+)
+
+// ProvisionEventType identifies the kind of a ProvisionEvent.
+type ProvisionEventType int
+
+// ProvisionEvent is a structured progress event parsed from the machine-readable output of a running command.
+type ProvisionEvent struct {
+	Type ProvisionEventType
+	// Machine is the machine the event refers to. It is empty for events about the environment as a whole.
+	Machine string
+	// Timestamp is the time at which vagrant reported the event. It is zero when the time is unavailable.
+	Timestamp time.Time
+	// Message is the text vagrant printed for the event, or the action name for action events.
+	Message string
+	// Progress is the percentage of a box download that has completed.
+	Progress int
+	// Provisioner is the name of the provisioner that started.
+	Provisioner string
+	// Err is the error the command failed with. It is only set on the DoneEvent.
+	Err error
+}
+
+// UpEvents runs up in the background and sends structured progress events on the returned channel as they occur. A
+// DoneEvent carrying the result of the command is sent last, after which the channel is closed. The caller must
+// receive events until the channel is closed or cancel the context, which kills the vagrant process. Once the context
+// is cancelled the remaining output, including a partial last line, is discarded and the DoneEvent is only sent if the
+// caller is still receiving, so the channel is closed promptly either way. All machines are targeted when no machine
+// names are given.
+func (w wrapper) UpEvents(ctx context.Context, machines ...string) (<-chan ProvisionEvent, error) {
+	if err := validateMachineNames(machines); err != nil {
+		return nil, err
+	}
+
+	ctx, done := w.procs.track(ctx)
+	events := make(chan ProvisionEvent)
+	send := func(event ProvisionEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	lw := &lineWriter{done: ctx.Done(), onLine: func(line string) {
+		entries, err := parseMachineReadable([]byte(line))
+		if err != nil {
+			w.logger.Debugf("Skipping up output line: %s", line)
+			return
+		}
+		for _, entry := range entries {
+			if event, ok := parseProvisionEvent(entry); ok {
+				send(event)
+			}
+		}
+	}}
+	if w.stdout != nil {
+		w.stdout = io.MultiWriter(w.stdout, lw)
+	} else {
+		w.stdout = lw
+	}
+
+	w.logger.Infof("Starting vagrant environment")
+	go func() {
+		defer done()
+		defer close(events)
+		_, err := w.exec(ctx, append([]string{"up", "--machine-readable", "--no-color"}, machines...)...)
+		lw.Flush()
+		send(ProvisionEvent{Type: DoneEvent, Timestamp: time.Now(), Err: err})
+	}()
+	return events, nil
+}
+
+// parseProvisionEvent converts a machine-readable entry into a ProvisionEvent. Entries that carry no progress
+// information, such as metadata, are skipped.
+func parseProvisionEvent(entry machineOutputEntry) (ProvisionEvent, bool) {
+	event := ProvisionEvent{Machine: entry.target, Timestamp: entry.timestamp}
+
+	switch entry.mType {
+	case "action":
+		if len(entry.data) < 2 {
+			return event, false
+		}
+		event.Message = entry.data[0]
+		switch entry.data[1] {
+		case "start":
+			event.Type = ActionStartedEvent
+		case "end":
+			event.Type = ActionFinishedEvent
+		default:
+			return event, false
+		}
+	case "ui":
+		if len(entry.data) < 2 {
+			return event, false
+		}
+		event.Message = strings.TrimSpace(strings.Join(entry.data[1:], ","))
+		if m := boxDownloadProgress.FindStringSubmatch(event.Message); m != nil {
+			event.Type = BoxDownloadEvent
+			event.Progress, _ = strconv.Atoi(m[1])
+		} else if m := provisionerStarted.FindStringSubmatch(event.Message); m != nil {
+			event.Type = ProvisionerStartedEvent
+			event.Provisioner = m[1]
+		} else if machineBooted.MatchString(event.Message) {
+			event.Type = MachineBootedEvent
+		} else {
+			event.Type = OutputEvent
+		}
+	default:
+		return event, false
+	}
+	return event, true
+}
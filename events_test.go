@@ -0,0 +1,165 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingRunner simulates a long stream of output by writing a line every millisecond until the context is
+// cancelled, followed by the output a killed process may still have buffered.
+type streamingRunner struct {
+	command.MockRunner
+	returned chan struct{}
+}
+
+func (r *streamingRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	defer close(r.returned)
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			stdout.Write([]byte("1602771201,default,ui,info,after cancel\n1602771201,default,ui,info,partial"))
+			return fmt.Errorf("%s interrupted: %w", cmd, ctx.Err())
+		case <-ticker.C:
+			stdout.Write([]byte("1602771200,default,ui,info,streaming\n"))
+		}
+	}
+}
+
+func TestUpEvents(t *testing.T) {
+	collect := func(events <-chan ProvisionEvent) []ProvisionEvent {
+		var collected []ProvisionEvent
+		for event := range events {
+			collected = append(collected, event)
+		}
+		return collected
+	}
+
+	t.Run("success", func(t *testing.T) {
+		out, err := ioutil.ReadFile("testdata/up-events")
+		require.NoError(t, err)
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: out}, "vagrant", "up", "--machine-readable", "--no-color", "default")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		events, err := w.UpEvents(context.Background(), "default")
+		require.NoError(t, err)
+		collected := collect(events)
+		require.Len(t, collected, 10)
+
+		assert.Equal(t, ProvisionEvent{
+			Type:      ActionStartedEvent,
+			Machine:   "default",
+			Timestamp: time.Unix(1602771200, 0),
+			Message:   "up",
+		}, collected[0])
+		assert.Equal(t, OutputEvent, collected[1].Type)
+		assert.Equal(t, "Bringing machine 'default' up with 'virtualbox' provider...", collected[1].Message)
+		assert.Equal(t, BoxDownloadEvent, collected[3].Type)
+		assert.Equal(t, 0, collected[3].Progress)
+		assert.Equal(t, BoxDownloadEvent, collected[4].Type)
+		assert.Equal(t, 57, collected[4].Progress)
+		assert.Equal(t, "Progress: 57% (Rate: 12.1M/s, Estimated time remaining: 0:00:08)", collected[4].Message)
+		assert.Equal(t, MachineBootedEvent, collected[5].Type)
+		assert.Equal(t, ProvisionerStartedEvent, collected[6].Type)
+		assert.Equal(t, "shell", collected[6].Provisioner)
+		assert.Equal(t, ActionFinishedEvent, collected[8].Type)
+
+		done := collected[9]
+		assert.Equal(t, DoneEvent, done.Type)
+		assert.NoError(t, done.Err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("up failed")}, "vagrant", "up", "--machine-readable", "--no-color")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		events, err := w.UpEvents(context.Background())
+		require.NoError(t, err)
+		collected := collect(events)
+
+		require.Len(t, collected, 1)
+		assert.Equal(t, DoneEvent, collected[0].Type)
+		assert.EqualError(t, collected[0].Err, "up failed")
+	})
+
+	t.Run("combined_output", func(t *testing.T) {
+		runner := &longRunningRunner{output: []byte("1602771200,default,action,up,start\n"), stopped: make(chan struct{})}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}, combined: true}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := w.UpEvents(ctx)
+		require.NoError(t, err)
+
+		// the event must arrive while vagrant is still running
+		select {
+		case event := <-events:
+			assert.Equal(t, ActionStartedEvent, event.Type)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received while the command was running")
+		}
+		cancel()
+		<-runner.stopped
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		runner := &longRunningRunner{output: []byte("1602771200,default,action,up,start\n"), stopped: make(chan struct{})}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events, err := w.UpEvents(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, ActionStartedEvent, (<-events).Type)
+
+		cancel()
+		<-runner.stopped
+		for range events {
+		}
+	})
+
+	t.Run("cancelled_mid_stream", func(t *testing.T) {
+		runner := &streamingRunner{returned: make(chan struct{})}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events, err := w.UpEvents(ctx)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, "streaming", (<-events).Message)
+		}
+
+		cancel()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					<-runner.returned
+					return
+				}
+				assert.NotContains(t, []string{"after cancel", "partial"}, event.Message)
+			case <-timeout:
+				t.Fatal("the event channel was not closed after cancelling")
+			}
+		}
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := wrapper{executable: binary, runner: &command.MockRunner{}, logger: &recordingLogger{}}
+
+		_, err := w.UpEvents(context.Background(), "web db")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,117 @@
+package vagrantexec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// globalStatusRow matches a single machine entry in the global-status table. The directory is the last column and
+// may contain whitespace.
+var globalStatusRow = regexp.MustCompile(`^([0-9a-f]+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S.*?)\s*$`)
+
+// machineID matches the ids of the machine index, shown in full or abbreviated by global-status.
+var machineID = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// GlobalMachineStatus describes a machine from any Vagrant environment known to this host.
+type GlobalMachineStatus struct {
+	ID        string
+	Name      string
+	Provider  string
+	State     MachineState
+	Directory string
+}
+
+// GlobalStatusOptions configures the behavior of Vagrant.GlobalStatus.
+type GlobalStatusOptions struct {
+	// Prune removes invalid entries from the machine index before reporting.
+	Prune bool
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// GlobalStatus reports the status of all machines in every Vagrant environment known to this host. The data is
+// cached by Vagrant and may be stale unless Prune is set.
+func (w wrapper) GlobalStatus(opts GlobalStatusOptions) ([]GlobalMachineStatus, error) {
+	return w.GlobalStatusContext(context.Background(), opts)
+}
+
+// GlobalStatusContext is like GlobalStatus but includes a context.
+func (w wrapper) GlobalStatusContext(ctx context.Context, opts GlobalStatusOptions) ([]GlobalMachineStatus, error) {
+	cmdArgs := []string{"global-status", "--no-color"}
+	if opts.Prune {
+		cmdArgs = append(cmdArgs, "--prune")
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := w.exec(ctx, cmdArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return parseGlobalStatus(out)
+}
+
+// Prune removes invalid entries from the machine index, such as machines whose environment directory has been deleted
+// or which were destroyed without vagrant updating the index. These entries otherwise show up in GlobalStatus.
+func (w wrapper) Prune() error {
+	return w.PruneContext(context.Background())
+}
+
+// PruneContext is like Prune but includes a context.
+func (w wrapper) PruneContext(ctx context.Context) error {
+	w.logger.Infof("Pruning the vagrant machine index")
+	_, err := w.exec(ctx, "global-status", "--no-color", "--prune")
+	return err
+}
+
+// DestroyByID destroys a machine of any environment known to this host by its id, as reported by GlobalStatus, without
+// the need to know the directory of its Vagrantfile. This makes it possible to clean up machines whose environment was
+// deleted or is not managed by this process. The command does not run in the directory of the wrapper, whose
+// Vagrantfile is neither required nor used, and never prompts for confirmation.
+func (w wrapper) DestroyByID(id string) error {
+	return w.DestroyByIDContext(context.Background(), id)
+}
+
+// DestroyByIDContext is like DestroyByID but includes a context.
+func (w wrapper) DestroyByIDContext(ctx context.Context, id string) error {
+	if !machineID.MatchString(id) {
+		return fmt.Errorf("invalid machine id %q", id)
+	}
+
+	w.logger.Infof("Deleting vagrant machine %s", id)
+	return w.rebind("").execLogOutput(ctx, "destroy", "--force", id)
+}
+
+// parseGlobalStatus extracts machine entries from the human-readable global-status table. The machine-readable
+// variant of this command is not reliable across vagrant versions.
+func parseGlobalStatus(out []byte) (statuses []GlobalMachineStatus, err error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(stripANSI(out))))
+
+	inTable := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !inTable {
+			inTable = strings.HasPrefix(line, "----") // skip everything up to the header separator
+			continue
+		}
+
+		ms := globalStatusRow.FindStringSubmatch(line)
+		if ms == nil {
+			break // the table ends at the first blank line or explanatory message
+		}
+		statuses = append(statuses, GlobalMachineStatus{
+			ID:        ms[1],
+			Name:      ms[2],
+			Provider:  ms[3],
+			State:     ToMachineState(ms[4]),
+			Directory: ms[5],
+		})
+	}
+	err = scanner.Err()
+	return
+}
@@ -0,0 +1,125 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalStatus(t *testing.T) {
+	mockGlobalStatus := mockedWrapperFn([]string{"global-status", "--no-color"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockGlobalStatus(ioutil.ReadFile("testdata/global-status"))
+
+		statuses, err := w.GlobalStatus(GlobalStatusOptions{})
+		require.NoError(t, err)
+
+		expected := []GlobalMachineStatus{
+			{
+				ID:        "1a2b3c4",
+				Name:      "default",
+				Provider:  "virtualbox",
+				State:     Running,
+				Directory: "/home/user/project",
+			},
+			{
+				ID:        "5d6e7f8",
+				Name:      "web",
+				Provider:  "virtualbox",
+				State:     PowerOff,
+				Directory: "/home/user/my project",
+			},
+			{
+				ID:        "9a0b1c2",
+				Name:      "db",
+				Provider:  "libvirt",
+				State:     PowerOff,
+				Directory: "/srv/vagrant/db",
+			},
+		}
+		assert.Equal(t, expected, statuses)
+	})
+
+	t.Run("no_environments", func(t *testing.T) {
+		w := mockGlobalStatus(ioutil.ReadFile("testdata/global-status-none"))
+
+		statuses, err := w.GlobalStatus(GlobalStatusOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, statuses)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"global-status", "--no-color", "--prune"})(ioutil.ReadFile("testdata/global-status-none"))
+
+		_, err := w.GlobalStatus(GlobalStatusOptions{Prune: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockGlobalStatus(nil, errors.New("runner error"))
+
+		_, err := w.GlobalStatus(GlobalStatusOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestPrune(t *testing.T) {
+	mockPrune := mockedWrapperFn([]string{"global-status", "--no-color", "--prune"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockPrune(ioutil.ReadFile("testdata/global-status"))
+		assert.NoError(t, w.Prune())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPrune(nil, errors.New("runner error"))
+		assert.Error(t, w.Prune())
+	})
+}
+
+func TestDestroyByID(t *testing.T) {
+	t.Run("outside_environment", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := New("testdata/does-not-exist", false, WithRunner(runner), WithLogger(&recordingLogger{}))
+
+		require.NoError(t, w.DestroyByID("1a2b3c4"))
+		require.Len(t, runner.Calls(), 1)
+		assert.Equal(t, "vagrant destroy --force 1a2b3c4", runner.Calls()[0].String())
+	})
+
+	t.Run("shell_runner", func(t *testing.T) {
+		w := New(".", false,
+			WithVagrantfile("testdata/env/Vagrantfile"),
+			WithEnv(map[string]string{"VAGRANT_LOG": "debug"}),
+		).(wrapper)
+
+		detached := w.rebind("")
+		assert.Empty(t, detached.vagrantfile)
+		r := detached.runner.(command.ShellRunner)
+		assert.Empty(t, r.Dir)
+		assert.Equal(t, []string{"VAGRANT_LOG=debug"}, r.Env)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("runner error")}, "vagrant", "destroy", "--force", "1a2b3c4")
+		w := New(".", false, WithRunner(runner), WithLogger(&recordingLogger{}))
+
+		assert.EqualError(t, w.DestroyByID("1a2b3c4"), "runner error")
+	})
+
+	t.Run("invalid_id", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := New(".", false, WithRunner(runner), WithLogger(&recordingLogger{}))
+
+		for _, id := range []string{"", "web", "1a2b 3c4", "--force", "1a2b3c4;ls"} {
+			assert.Error(t, w.DestroyByID(id), id)
+		}
+		assert.Empty(t, runner.Calls())
+	})
+}
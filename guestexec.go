@@ -0,0 +1,65 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+// notWinRMPattern matches the error vagrant winrm-config prints for machines whose communicator is not WinRM.
+var notWinRMPattern = regexp.MustCompile(`(?i)winrm as (its|the) communicator|configured to use winrm`)
+
+// GuestExec runs a command on a machine over its configured communicator and returns the output. Machines using WinRM,
+// typically Windows guests, run the command with vagrant winrm, all others with vagrant ssh. The communicator is
+// detected with vagrant winrm-config, so every call runs an additional vagrant command. You can use an empty string as
+// the machine if you only have one VM defined in your Vagrantfile.
+func (w wrapper) GuestExec(machine, cmd string) (string, error) {
+	return w.GuestExecContext(context.Background(), machine, cmd)
+}
+
+// GuestExecContext is like GuestExec but includes a context.
+func (w wrapper) GuestExecContext(ctx context.Context, machine, cmd string) (string, error) {
+	if len(machine) > 0 {
+		if err := validateMachineNames([]string{machine}); err != nil {
+			return "", err
+		}
+	}
+
+	winrm, err := w.usesWinRM(ctx, machine)
+	if err != nil {
+		return "", err
+	}
+	if !winrm {
+		return w.SSHWithOptionsContext(ctx, cmd, SSHOptions{Machine: machine})
+	}
+
+	cmdArgs := []string{"winrm", "--command", cmd}
+	if len(machine) > 0 {
+		cmdArgs = append(cmdArgs, machine)
+	}
+	out, err := w.exec(ctx, cmdArgs...)
+	return string(out), err
+}
+
+// usesWinRM reports whether a machine is configured to use the WinRM communicator. An error is returned when the
+// communicator cannot be determined, e.g. because the machine is not created.
+func (w wrapper) usesWinRM(ctx context.Context, machine string) (bool, error) {
+	cmdArgs := []string{"winrm-config"}
+	if len(machine) > 0 {
+		cmdArgs = append(cmdArgs, machine)
+	}
+
+	_, err := w.exec(ctx, cmdArgs...)
+	var ee command.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &ee) && notWinRMPattern.MatchString(ee.Stderr()):
+		return false, nil
+	default:
+		return false, fmt.Errorf("cannot determine the communicator of the machine: %w", err)
+	}
+}
@@ -0,0 +1,55 @@
+package vagrantexec
+
+import (
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuestExec(t *testing.T) {
+	notWinRM := command.NewExitError("vagrant", 1, "The winrm command requires a machine configured to use WinRM as its communicator.")
+
+	t.Run("winrm", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: []byte("Host win\n  HostName 127.0.0.1\n")}, "vagrant", "winrm-config", "win")
+		runner.AddResponse(command.Response{Output: []byte("Windows IP Configuration\n")}, "vagrant", "winrm", "--command", "ipconfig", "win")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		out, err := w.GuestExec("win", "ipconfig")
+		require.NoError(t, err)
+		assert.Equal(t, "Windows IP Configuration\n", out)
+		assert.Len(t, runner.Calls(), 2)
+	})
+
+	t.Run("ssh", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: notWinRM}, "vagrant", "winrm-config")
+		runner.AddResponse(command.Response{Output: []byte("Linux\n")}, "vagrant", "ssh", "--no-tty", "--command", "uname")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		out, err := w.GuestExec("", "uname")
+		require.NoError(t, err)
+		assert.Equal(t, "Linux\n", out)
+	})
+
+	t.Run("unknown_communicator", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "The machine must be created before running this command.")},
+			"vagrant", "winrm-config", "win")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		_, err := w.GuestExec("win", "ipconfig")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot determine the communicator of the machine")
+		assert.True(t, IsMachineNotCreated(err))
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := wrapper{executable: binary, runner: &command.MockRunner{}, logger: &recordingLogger{}}
+		_, err := w.GuestExec("-win", "ipconfig")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,78 @@
+package vagrantexec
+
+import "time"
+
+// ExecHook is called after every vagrant invocation with the executable, its arguments, the wall-clock duration of
+// the invocation and the error it returned, if any. Arguments given to flags like --token are redacted. Each retry
+// attempt is reported separately.
+type ExecHook func(cmd string, args []string, dur time.Duration, err error)
+
+// WithExecHook registers a hook that is called after every vagrant invocation, as well as after every host tool such
+// as virsh or scp run on behalf of a method, for instance to record how long commands take in a metrics system. Hooks
+// run synchronously in the order they were registered, so they should return quickly. Repeated calls add to the previously registered hooks.
+func WithExecHook(hook ExecHook) Option {
+	return func(w *wrapper) {
+		w.hooks = append(w.hooks, hook)
+	}
+}
+
+// runHooks reports a completed invocation to the registered hooks.
+func (w wrapper) runHooks(args []string, dur time.Duration, err error) {
+	if len(w.hooks) == 0 {
+		return
+	}
+	redacted, _ := w.redactArgs(args)
+	for _, hook := range w.hooks {
+		hook(w.executable, redacted, dur, err)
+	}
+}
+
+// ExecObserver is notified around every vagrant invocation, which allows collecting metrics such as command counts,
+// durations and error rates without this package depending on a metrics library. cmd is the vagrant subcommand, e.g.
+// "up", or the name of a host tool such as "virsh" run on behalf of a method, and args are all arguments passed to vagrant with the values of flags like --token redacted. Each retry
+// attempt is observed separately. Implementations must be safe for concurrent use if the wrapper is.
+type ExecObserver interface {
+	ObserveStart(cmd string, args []string)
+	ObserveEnd(cmd string, dur time.Duration, err error)
+}
+
+// NopObserver is an ExecObserver that does nothing. It is the default.
+type NopObserver struct{}
+
+// ObserveStart does nothing.
+func (NopObserver) ObserveStart(cmd string, args []string) {}
+
+// ObserveEnd does nothing.
+func (NopObserver) ObserveEnd(cmd string, dur time.Duration, err error) {}
+
+// WithObserver registers an ExecObserver that is notified before and after every vagrant invocation. It replaces any
+// previously configured observer.
+func WithObserver(observer ExecObserver) Option {
+	return func(w *wrapper) {
+		w.observer = observer
+	}
+}
+
+// observeStart notifies the observer, if any, that an invocation is starting.
+func (w wrapper) observeStart(args []string) {
+	if w.observer == nil {
+		return
+	}
+	redacted, _ := w.redactArgs(args)
+	w.observer.ObserveStart(w.observedCommand(args), redacted)
+}
+
+// observeEnd notifies the observer, if any, that an invocation has completed.
+func (w wrapper) observeEnd(args []string, dur time.Duration, err error) {
+	if w.observer != nil {
+		w.observer.ObserveEnd(w.observedCommand(args), dur, err)
+	}
+}
+
+// observedCommand returns the vagrant subcommand of args, or the name of the tool for host tools like virsh.
+func (w wrapper) observedCommand(args []string) string {
+	if w.hostTool {
+		return w.executable
+	}
+	return args[0]
+}
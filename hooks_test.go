@@ -0,0 +1,144 @@
+package vagrantexec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hookCall struct {
+	cmd  string
+	args []string
+	dur  time.Duration
+	err  error
+}
+
+func TestWithExecHook(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var calls []hookCall
+		hook := func(cmd string, args []string, dur time.Duration, err error) {
+			calls = append(calls, hookCall{cmd, args, dur, err})
+		}
+		w := New(".", false, WithRunner(&command.MockRunner{}), WithExecHook(hook)).(wrapper)
+		w.dir = ""
+
+		require.NoError(t, w.Up("web"))
+		require.Len(t, calls, 1)
+		assert.Equal(t, "vagrant", calls[0].cmd)
+		assert.Equal(t, []string{"up", "web"}, calls[0].args)
+		assert.True(t, calls[0].dur >= 0)
+		assert.NoError(t, calls[0].err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("runner error")}, "vagrant", "cloud", "auth", "login", "--token", "s3cr3t")
+
+		var calls []hookCall
+		hook := func(cmd string, args []string, dur time.Duration, err error) {
+			calls = append(calls, hookCall{cmd, args, dur, err})
+		}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+		WithExecHook(hook)(&w)
+
+		assert.Error(t, w.CloudLogin("s3cr3t"))
+		require.Len(t, calls, 1)
+		assert.Equal(t, []string{"cloud", "auth", "login", "--token", "***"}, calls[0].args)
+		assert.EqualError(t, calls[0].err, "runner error")
+	})
+
+	t.Run("multiple_hooks", func(t *testing.T) {
+		var order []string
+		w := New(".", false,
+			WithRunner(&command.MockRunner{}),
+			WithExecHook(func(string, []string, time.Duration, error) { order = append(order, "first") }),
+			WithExecHook(func(string, []string, time.Duration, error) { order = append(order, "second") }),
+		).(wrapper)
+		w.dir = ""
+
+		require.NoError(t, w.Halt())
+		assert.Equal(t, []string{"first", "second"}, order)
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "Could not resolve host: app.vagrantup.com")}, "vagrant", "up")
+		runner.AddResponse(command.Response{}, "vagrant", "up")
+
+		var calls int
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+		WithRetry(3, time.Millisecond)(&w)
+		WithExecHook(func(string, []string, time.Duration, error) { calls++ })(&w)
+
+		require.NoError(t, w.Up())
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		var calls int
+		w := wrapper{executable: binary, runner: &command.MockRunner{}, logger: &recordingLogger{}, dryRun: true}
+		WithExecHook(func(string, []string, time.Duration, error) { calls++ })(&w)
+
+		require.NoError(t, w.Up())
+		assert.Zero(t, calls)
+	})
+}
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	starts []string
+	ends   []string
+}
+
+func (o *recordingObserver) ObserveStart(cmd string, args []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.starts = append(o.starts, cmd+" "+strings.Join(args, " "))
+}
+
+func (o *recordingObserver) ObserveEnd(cmd string, dur time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ends = append(o.ends, fmt.Sprintf("%s %v", cmd, err))
+}
+
+func TestWithObserver(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		w := New(".", false).(wrapper)
+		assert.Equal(t, NopObserver{}, w.observer)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		observer := &recordingObserver{}
+		w := New(".", false, WithRunner(&command.MockRunner{}), WithObserver(observer)).(wrapper)
+		w.dir = ""
+
+		require.NoError(t, w.Up("web"))
+		assert.Equal(t, []string{"up up web"}, observer.starts)
+		assert.Equal(t, []string{"up <nil>"}, observer.ends)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("runner error")}, "vagrant", "cloud", "auth", "login", "--token", "s3cr3t")
+
+		observer := &recordingObserver{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}, observer: observer}
+
+		assert.Error(t, w.CloudLogin("s3cr3t"))
+		assert.Equal(t, []string{"cloud cloud auth login --token ***"}, observer.starts)
+		assert.Equal(t, []string{"cloud runner error"}, observer.ends)
+	})
+
+	t.Run("nil_observer", func(t *testing.T) {
+		w := wrapper{executable: binary, runner: &command.MockRunner{}, logger: &recordingLogger{}}
+		assert.NoError(t, w.Halt())
+	})
+}
@@ -0,0 +1,74 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrVagrantfileExists is returned by Init when the Vagrantfile it would create already exists and Force is not set.
+var ErrVagrantfileExists = errors.New("Vagrantfile already exists")
+
+// InitOptions configures the behavior of Vagrant.Init.
+type InitOptions struct {
+	// BoxVersion pins the version of the box in the generated Vagrantfile.
+	BoxVersion string
+	// Force overwrites an existing Vagrantfile.
+	Force bool
+	// Minimal generates a Vagrantfile without the explanatory comments.
+	Minimal bool
+	// Output is the path of the Vagrantfile to create. Relative paths are resolved against the Vagrantfile directory.
+	// Defaults to Vagrantfile.
+	Output string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// Init creates a Vagrantfile in the Vagrantfile directory that uses the given box. A Vagrantfile with a placeholder
+// box is created when box is empty. ErrVagrantfileExists is returned instead of overwriting an existing file unless
+// Force is set.
+func (w wrapper) Init(box string, opts InitOptions) error {
+	return w.InitContext(context.Background(), box, opts)
+}
+
+// InitContext is like Init but includes a context.
+func (w wrapper) InitContext(ctx context.Context, box string, opts InitOptions) error {
+	cmdArgs := []string{"init"}
+
+	output := opts.Output
+	if len(output) == 0 {
+		output = "Vagrantfile"
+	} else {
+		cmdArgs = append(cmdArgs, "--output", output)
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(w.dir, output)
+	}
+	if opts.Force {
+		cmdArgs = append(cmdArgs, "--force")
+	} else if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("%w: %s", ErrVagrantfileExists, output)
+	}
+
+	if opts.Minimal {
+		cmdArgs = append(cmdArgs, "--minimal")
+	}
+	if len(opts.BoxVersion) > 0 {
+		if len(box) == 0 {
+			return errors.New("box version requires a box")
+		}
+		cmdArgs = append(cmdArgs, "--box-version", opts.BoxVersion)
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	if len(box) > 0 {
+		cmdArgs = append(cmdArgs, box)
+	}
+
+	w.logger.Infof("Initializing vagrant environment")
+	return w.execLogOutput(ctx, cmdArgs...)
+}
@@ -0,0 +1,65 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vagrant-exec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	newInit := func(runnerArgs []string, out []byte, err error) wrapper {
+		w := mockedWrapperFn(runnerArgs)(out, err)
+		w.dir = dir
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := newInit([]string{"init", "hashicorp/bionic64"}, []byte("A `Vagrantfile` has been placed in this directory."), nil)
+		assert.NoError(t, w.Init("hashicorp/bionic64", InitOptions{}))
+	})
+
+	t.Run("default_box", func(t *testing.T) {
+		w := newInit([]string{"init"}, nil, nil)
+		assert.NoError(t, w.Init("", InitOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := newInit([]string{"init"}, nil, errors.New("runner error"))
+		assert.Error(t, w.Init("", InitOptions{}))
+	})
+
+	t.Run("all_options", func(t *testing.T) {
+		w := newInit([]string{"init", "--output", "Vagrantfile.web", "--minimal", "--box-version", "1.0.0", "ubuntu/focal64"}, nil, nil)
+		assert.NoError(t, w.Init("ubuntu/focal64", InitOptions{
+			BoxVersion: "1.0.0",
+			Minimal:    true,
+			Output:     "Vagrantfile.web",
+		}))
+	})
+
+	t.Run("existing_vagrantfile", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Vagrantfile"), nil, 0644))
+		defer os.Remove(filepath.Join(dir, "Vagrantfile"))
+
+		w := newInit([]string{"init"}, nil, nil)
+		err := w.Init("", InitOptions{})
+		assert.True(t, errors.Is(err, ErrVagrantfileExists))
+
+		w = newInit([]string{"init", "--force"}, nil, nil)
+		assert.NoError(t, w.Init("", InitOptions{Force: true}))
+	})
+
+	t.Run("box_version_without_box", func(t *testing.T) {
+		w := newInit([]string{"init"}, nil, nil)
+		assert.Error(t, w.Init("", InitOptions{BoxVersion: "1.0.0"}))
+	})
+}
@@ -0,0 +1,152 @@
+package vagrantexec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultLibvirtURI is the connection used by vagrant-libvirt unless the Vagrantfile configures another one.
+	defaultLibvirtURI = "qemu:///system"
+	// defaultLibvirtPool is the storage pool in which vagrant-libvirt creates volumes by default.
+	defaultLibvirtPool = "default"
+	// virshBinary is the libvirt command line tool used to manage volumes.
+	virshBinary = "virsh"
+)
+
+// LibvirtVolumeOptions configures the behavior of Vagrant.LibvirtVolumes.
+type LibvirtVolumeOptions struct {
+	// URI is the libvirt connection URI. Defaults to qemu:///system, the default of vagrant-libvirt.
+	URI string
+	// Pool is the storage pool to list. Defaults to the "default" pool.
+	Pool string
+	// Prefix is the prefix vagrant-libvirt gives the volumes of this environment, set with default_prefix in the
+	// Vagrantfile. Defaults to the name of the Vagrantfile directory followed by an underscore.
+	Prefix string
+	// Prune deletes the orphaned volumes. They are still returned.
+	Prune bool
+}
+
+// Volume is a libvirt storage volume created by vagrant-libvirt for a machine of the environment.
+type Volume struct {
+	// Name is the name of the volume in the storage pool.
+	Name string
+	// Path is the location of the volume on the libvirt host.
+	Path string
+	// Machine is the machine the volume was created for. It is empty when no machine of the environment matches, in
+	// which case the volume may belong to another environment whose directory has the same name or to a machine that
+	// was removed from the Vagrantfile.
+	Machine string
+	// Orphaned is true when the machine of the volume is defined in the Vagrantfile but not created, which happens when
+	// a destroy fails halfway. Volumes without a machine are never orphaned.
+	Orphaned bool
+}
+
+// LibvirtVolumes lists the libvirt volumes named after this environment using virsh, which must be installed on the
+// host. Volumes of machines that are not created are reported as orphaned and deleted when Prune is set. Volumes that
+// match no machine of the Vagrantfile are listed but left alone, since nothing proves they are unused. Nothing is done
+// and nil is returned when no machine of the environment uses the libvirt provider.
+func (w wrapper) LibvirtVolumes(opts LibvirtVolumeOptions) ([]Volume, error) {
+	return w.LibvirtVolumesContext(context.Background(), opts)
+}
+
+// LibvirtVolumesContext is like LibvirtVolumes but includes a context.
+func (w wrapper) LibvirtVolumesContext(ctx context.Context, opts LibvirtVolumeOptions) ([]Volume, error) {
+	if w.dryRun {
+		w.logger.Infof("Dry run, not listing libvirt volumes")
+		return nil, nil
+	}
+
+	statuses, err := w.StatusContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	libvirt := false
+	for _, status := range statuses {
+		if status.Provider == "libvirt" {
+			libvirt = true
+			break
+		}
+	}
+	if !libvirt {
+		w.logger.Debugf("No machine uses the libvirt provider, skipping volume listing")
+		return nil, nil
+	}
+
+	if len(opts.URI) == 0 {
+		opts.URI = defaultLibvirtURI
+	}
+	if len(opts.Pool) == 0 {
+		opts.Pool = defaultLibvirtPool
+	}
+	if len(opts.Prefix) == 0 {
+		dir, err := w.vagrantfileDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Prefix = filepath.Base(dir) + "_"
+	}
+
+	out, err := w.execTool(ctx, virshBinary, "--connect", opts.URI, "vol-list", "--pool", opts.Pool)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list libvirt volumes: %w", err)
+	}
+	volumes := environmentVolumes(parseVolumeList(out), opts.Prefix, statuses)
+
+	if opts.Prune {
+		for _, volume := range volumes {
+			if !volume.Orphaned {
+				continue
+			}
+			w.logger.Infof("Deleting orphaned libvirt volume: %s", volume.Name)
+			_, err := w.execTool(ctx, virshBinary, "--connect", opts.URI, "vol-delete", "--pool", opts.Pool, volume.Name)
+			if err != nil {
+				return volumes, fmt.Errorf("cannot delete libvirt volume %s: %w", volume.Name, err)
+			}
+		}
+	}
+	return volumes, nil
+}
+
+// parseVolumeList extracts the volumes from the table printed by virsh vol-list. The header and separator lines are
+// skipped.
+func parseVolumeList(out []byte) []Volume {
+	var volumes []Volume
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || (fields[0] == "Name" && fields[1] == "Path") || strings.HasPrefix(fields[0], "---") {
+			continue
+		}
+		volumes = append(volumes, Volume{Name: fields[0], Path: fields[1]})
+	}
+	return volumes
+}
+
+// environmentVolumes returns the volumes whose name starts with prefix and assigns them to machines. vagrant-libvirt
+// names the boot volume of a machine <prefix><machine>.img and additional disks <prefix><machine>-<disk>.<format>.
+func environmentVolumes(volumes []Volume, prefix string, statuses []MachineStatus) []Volume {
+	var matched []Volume
+	for _, volume := range volumes {
+		name := strings.TrimPrefix(volume.Name, prefix)
+		if name == volume.Name {
+			continue
+		}
+
+		for _, status := range statuses {
+			if name == status.Name+".img" || strings.HasPrefix(name, status.Name+"-") {
+				// prefer the longest machine name, e.g. web-db over web for web-db.img
+				if len(status.Name) > len(volume.Machine) {
+					volume.Machine = status.Name
+					volume.Orphaned = status.State == NotCreated
+				}
+			}
+		}
+		matched = append(matched, volume)
+	}
+	return matched
+}
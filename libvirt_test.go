@@ -0,0 +1,101 @@
+package vagrantexec
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibvirtVolumes(t *testing.T) {
+	statusArgs := []string{"status", "--machine-readable", "--no-color"}
+	libvirtStatus := []byte("1562175814,web,provider-name,libvirt\n1562175814,web,state,running\n" +
+		"1562175814,web-db,provider-name,libvirt\n1562175814,web-db,state,running\n" +
+		"1562175814,db,provider-name,libvirt\n1562175814,db,state,not_created\n")
+	listArgs := []string{"--connect", "qemu:///system", "vol-list", "--pool", "default"}
+
+	newLibvirt := func(t *testing.T, status []byte) (wrapper, *command.MockRunner) {
+		volumes, err := ioutil.ReadFile("testdata/virsh-vol-list")
+		require.NoError(t, err)
+
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: status}, "vagrant", statusArgs...)
+		runner.AddResponse(command.Response{Output: volumes}, "virsh", listArgs...)
+		return wrapper{executable: binary, dir: "testdata/env", runner: runner, logger: &recordingLogger{}}, runner
+	}
+
+	t.Run("list", func(t *testing.T) {
+		w, runner := newLibvirt(t, libvirtStatus)
+
+		volumes, err := w.LibvirtVolumes(LibvirtVolumeOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []Volume{
+			{Name: "env_db.img", Path: "/var/lib/libvirt/images/env_db.img", Machine: "db", Orphaned: true},
+			{Name: "env_web-db-vdb.qcow2", Path: "/var/lib/libvirt/images/env_web-db-vdb.qcow2", Machine: "web-db"},
+			{Name: "env_web.img", Path: "/var/lib/libvirt/images/env_web.img", Machine: "web"},
+			{Name: "env_worker.img", Path: "/var/lib/libvirt/images/env_worker.img"},
+		}, volumes)
+		assert.Len(t, runner.Calls(), 2)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		w, runner := newLibvirt(t, libvirtStatus)
+
+		volumes, err := w.LibvirtVolumes(LibvirtVolumeOptions{Prune: true})
+		require.NoError(t, err)
+		assert.Len(t, volumes, 4)
+
+		// env_worker.img matches no machine and may belong to another environment, so it is kept
+		calls := runner.Calls()
+		require.Len(t, calls, 3)
+		assert.Equal(t, "virsh --connect qemu:///system vol-delete --pool default env_db.img", calls[2].String())
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		w, _ := newLibvirt(t, libvirtStatus)
+		var commands []string
+		WithExecHook(func(cmd string, args []string, dur time.Duration, err error) {
+			commands = append(commands, cmd+" "+strings.Join(args, " "))
+		})(&w)
+
+		_, err := w.LibvirtVolumes(LibvirtVolumeOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"vagrant status --machine-readable --no-color",
+			"virsh --connect qemu:///system vol-list --pool default",
+		}, commands)
+	})
+
+	t.Run("custom_pool_and_prefix", func(t *testing.T) {
+		w, runner := newLibvirt(t, libvirtStatus)
+		runner.AddResponse(command.Response{Output: []byte(" Name   Path\n---------\n dev_web.img   /pool/dev_web.img\n")},
+			"virsh", "--connect", "qemu+ssh://host/system", "vol-list", "--pool", "vms")
+
+		volumes, err := w.LibvirtVolumes(LibvirtVolumeOptions{URI: "qemu+ssh://host/system", Pool: "vms", Prefix: "dev_"})
+		require.NoError(t, err)
+		assert.Equal(t, []Volume{{Name: "dev_web.img", Path: "/pool/dev_web.img", Machine: "web"}}, volumes)
+	})
+
+	t.Run("other_provider", func(t *testing.T) {
+		w, runner := newLibvirt(t, []byte("1562175814,web,provider-name,virtualbox\n1562175814,web,state,running\n"))
+
+		volumes, err := w.LibvirtVolumes(LibvirtVolumeOptions{Prune: true})
+		require.NoError(t, err)
+		assert.Nil(t, volumes)
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("virsh_error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: libvirtStatus}, "vagrant", statusArgs...)
+		runner.AddResponse(command.Response{Err: command.NewExitError("virsh", 1, "failed to connect to the hypervisor")}, "virsh", listArgs...)
+		w := wrapper{executable: binary, dir: "testdata/env", runner: runner, logger: &recordingLogger{}}
+
+		_, err := w.LibvirtVolumes(LibvirtVolumeOptions{})
+		assert.Error(t, err)
+	})
+}
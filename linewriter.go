@@ -0,0 +1,49 @@
+package vagrantexec
+
+import "bytes"
+
+// lineWriter is an io.Writer that calls onLine with each complete line written to it, without the line ending.
+type lineWriter struct {
+	onLine func(line string)
+	// done stops the writer once it is closed, usually with the context of the command: output written afterwards,
+	// along with any partial line still buffered, is discarded instead of being passed to onLine.
+	done <-chan struct{}
+	buf  bytes.Buffer
+}
+
+// Write never fails, so that the command is not interrupted by a broken pipe while it is still running or being
+// killed.
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf.Write(p)
+	for {
+		if l.stopped() {
+			l.buf.Reset()
+			break
+		}
+		i := bytes.IndexByte(l.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := l.buf.Next(i + 1)
+		l.onLine(string(bytes.TrimRight(line, "\r\n")))
+	}
+	return len(p), nil
+}
+
+// Flush passes any trailing output that did not end with a newline to onLine, unless the writer has been stopped.
+func (l *lineWriter) Flush() {
+	if l.buf.Len() > 0 && !l.stopped() {
+		l.onLine(l.buf.String())
+	}
+	l.buf.Reset()
+}
+
+// stopped reports whether done has been closed.
+func (l *lineWriter) stopped() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
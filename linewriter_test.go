@@ -0,0 +1,39 @@
+package vagrantexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	lw := &lineWriter{onLine: func(line string) { lines = append(lines, line) }}
+
+	lw.Write([]byte("first li"))
+	lw.Write([]byte("ne\r\nsecond line\nthi"))
+	assert.Equal(t, []string{"first line", "second line"}, lines)
+
+	lw.Write([]byte("rd"))
+	lw.Flush()
+	assert.Equal(t, []string{"first line", "second line", "third"}, lines)
+
+	lw.Flush()
+	assert.Len(t, lines, 3)
+}
+
+func TestLineWriterDone(t *testing.T) {
+	var lines []string
+	done := make(chan struct{})
+	lw := &lineWriter{done: done, onLine: func(line string) { lines = append(lines, line) }}
+
+	lw.Write([]byte("first line\nsecond li"))
+	close(done)
+	n, err := lw.Write([]byte("ne\nthird line\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 14, n)
+
+	lw.Write([]byte("partial"))
+	lw.Flush()
+	assert.Equal(t, []string{"first line"}, lines)
+}
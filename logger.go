@@ -0,0 +1,93 @@
+package vagrantexec
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Logger is the logging interface used by the Vagrant wrapper. Command progress is logged at Info level and the
+// commands being run, along with their raw output, at Debug level.
+//
+// A *logrus.Logger, which is the default, or any logrus.FieldLogger satisfies it directly, as does a *zap.SugaredLogger.
+// Other logging libraries can be adapted with a small wrapper type and passed to WithLogger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+var _ Logger = log.FieldLogger(nil)
+
+// NopLogger is a Logger that discards every message. See WithQuiet.
+type NopLogger struct{}
+
+// Debugf does nothing.
+func (NopLogger) Debugf(format string, args ...interface{}) {}
+
+// Infof does nothing.
+func (NopLogger) Infof(format string, args ...interface{}) {}
+
+// Warnf does nothing.
+func (NopLogger) Warnf(format string, args ...interface{}) {}
+
+// slogLogger adapts a *slog.Logger to the Logger interface. Messages are formatted with fmt.Sprintf and logged at the
+// matching slog level:
+//
+//	Debugf -> slog.LevelDebug (commands being run and their raw output)
+//	Infof  -> slog.LevelInfo  (command progress and vagrant output when it is not streamed)
+//	Warnf  -> slog.LevelWarn  (retries of transient failures)
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Debugf(format string, args ...interface{}) {
+	l.logf(slog.LevelDebug, format, args...)
+}
+
+func (l slogLogger) Infof(format string, args ...interface{}) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+func (l slogLogger) Warnf(format string, args ...interface{}) {
+	l.logf(slog.LevelWarn, format, args...)
+}
+
+func (l slogLogger) logf(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	l.logger.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+// debugFields logs msg at Debug level with structured fields. Loggers that support fields, like logrus and slog,
+// receive them as fields; any other Logger gets them appended to the message as sorted key=value pairs.
+func debugFields(logger Logger, msg string, fields log.Fields) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch l := logger.(type) {
+	case log.FieldLogger:
+		l.WithFields(fields).Debug(msg)
+	case slogLogger:
+		attrs := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, slog.Any(k, fields[k]))
+		}
+		l.logger.Log(context.Background(), slog.LevelDebug, msg, attrs...)
+	default:
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, fields[k]))
+		}
+		logger.Debugf("%s %s", msg, strings.Join(pairs, " "))
+	}
+}
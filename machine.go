@@ -2,8 +2,14 @@ package vagrantexec
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -34,14 +40,16 @@ const (
 )
 
 var (
-	// stateStrList contains a list of state string representations based on the ordinal values of the constants.
+	// stateStrList contains the canonical vagrant name of each state based on the ordinal values of the constants.
 	stateStrList = []string{
-		"Unknown", "Aborted", "GuruMeditation", "Inaccessible", "NotCreated",
-		"Paused", "PowerOff", "Stopping", "Running", "Saving", "Saved", "Stuck",
+		"unknown", "aborted", "gurumeditation", "inaccessible", "not_created",
+		"paused", "poweroff", "stopping", "running", "saving", "saved", "stuck",
 	}
 
-	// strStateMap maps vagrant state output to their corresponding constants.
+	// strStateMap maps vagrant output to their corresponding constants. Providers use their own vocabulary for some
+	// states, so every variant of "created but not running" is normalized to PowerOff.
 	strStateMap = map[string]MachineState{
+		// virtualbox, and states shared by most providers
 		"running":        Running,
 		"not_created":    NotCreated,
 		"saved":          Saved,
@@ -53,6 +61,21 @@ var (
 		"stuck":          Stuck,
 		"inaccessible":   Inaccessible,
 		"gurumeditation": GuruMeditation,
+
+		// docker
+		"stopped": PowerOff,
+
+		// libvirt
+		"shutoff":  PowerOff,
+		"shutdown": Stopping,
+		"crashed":  Aborted,
+
+		// hyperv
+		"off": PowerOff,
+
+		// vmware
+		"not_running": PowerOff,
+		"suspended":   Saved,
 	}
 
 	// runnableStates contains a list of recoverable vagrant states.
@@ -71,21 +94,46 @@ var (
 // A list of all available states can be found here: https://github.com/hashicorp/vagrant/blob/4ce8d84f7e6709e4478612a9f0810dc686076ee0/templates/locales/en.yml#L2056
 type MachineState int
 
-// ToMachineState converts a string into a MachineState. An Unknown state is returned if the string is invalid.
+// ToMachineState converts a string into a MachineState. Provider-specific names for a stopped machine, such as
+// "stopped" (docker), "shutoff" (libvirt), "off" (hyperv) and "not_running" (vmware), all map to PowerOff. An Unknown
+// state is returned if the string is not recognized.
 func ToMachineState(str string) MachineState {
 	return strStateMap[str]
 }
 
-// String returns a string representation of the MachineState.
+// String returns the canonical lowercase name of the MachineState, as reported by the virtualbox provider.
 func (s MachineState) String() string {
+	if s < 0 || int(s) >= len(stateStrList) {
+		return stateStrList[Unknown]
+	}
 	return stateStrList[s]
 }
 
+// MarshalJSON encodes the MachineState as its canonical name.
+func (s MachineState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a MachineState from its name. Any name accepted by ToMachineState is allowed.
+func (s *MachineState) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("machine state must be a string: %w", err)
+	}
+	*s = ToMachineState(str)
+	return nil
+}
+
 // MachineStatus encompasses the machine metadata provided by Vagrant.
 type MachineStatus struct {
-	Name     string
-	Provider string
-	State    MachineState
+	// ID is the short id Vagrant assigned to the machine, as shown by global-status. It is empty when the machine has
+	// not been created.
+	ID       string       `json:"id,omitempty"`
+	Name     string       `json:"name"`
+	Provider string       `json:"provider"`
+	State    MachineState `json:"state"`
+	// LastUpdated is the time at which Vagrant reported the state. It is zero when the time is unavailable.
+	LastUpdated time.Time `json:"last_updated"`
 }
 
 // IsRunning returns true if the virtual machine is in a running state.
@@ -103,35 +151,165 @@ func (m MachineStatus) IsRunnable() (runnable bool) {
 	return
 }
 
+// FilterStatuses returns the statuses whose state is one of the given states, keeping their order.
+func FilterStatuses(statuses []MachineStatus, states ...MachineState) []MachineStatus {
+	var filtered []MachineStatus
+	for _, status := range statuses {
+		if slices.Contains(states, status.State) {
+			filtered = append(filtered, status)
+		}
+	}
+	return filtered
+}
+
 // machineOutputEntry defines all of the components in a single line of machine-readable output.
 //
 // See https://www.vagrantup.com/docs/cli/machine-readable.html#format for more details.
 type machineOutputEntry struct {
-	timestamp string
+	timestamp time.Time
 	target    string
 	mType     string
 	data      []string
 }
 
-// parseMachineReadable converts machine-readable output into a slice of machineOutputEntry.
+// ansiEscape matches ANSI terminal escape sequences, such as colors, that some vagrant versions print even when
+// --no-color or --machine-readable is given.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// stripANSI removes ANSI terminal escape sequences from command output.
+func stripANSI(out []byte) []byte {
+	return ansiEscape.ReplaceAll(out, nil)
+}
+
+// machineReadableEscapes reverses the escaping vagrant applies to commas and newlines within machine-readable fields.
+var machineReadableEscapes = strings.NewReplacer(`%!(VAGRANT_COMMA)`, ",", `\n`, "\n", `\r`, "\r")
+
+// parseMachineReadable converts machine-readable output into a slice of machineOutputEntry. Malformed rows are
+// skipped and reported together in the returned error, so callers that can tolerate them may use the valid entries.
+// Lines may end with LF or, as on Windows hosts, CRLF; the scanner drops the carriage return before splitting fields.
+//
+// The number of data fields varies between vagrant versions and entry types, so callers must check the length of data
+// before indexing past the first field, which every valid row has. Blank lines are skipped rather than reported, and
+// rows without any data field are reported as malformed.
 func parseMachineReadable(machineOut []byte) (entries []machineOutputEntry, err error) {
-	scanner := bufio.NewScanner(strings.NewReader(string(machineOut)))
+	var errs []error
+	scanner := bufio.NewScanner(strings.NewReader(string(stripANSI(machineOut))))
 	for scanner.Scan() {
 		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
 		row := strings.Split(line, ",")
 		if len(row) < 4 {
-			err = fmt.Errorf("invalid machine-readable format: %s", row)
-			return
+			errs = append(errs, fmt.Errorf("invalid machine-readable format: %s", row))
+			continue
+		}
+
+		data := row[3:]
+		for i := range data {
+			data[i] = machineReadableEscapes.Replace(data[i])
 		}
 
 		entries = append(entries, machineOutputEntry{
-			timestamp: row[0],
+			timestamp: parseTimestamp(row[0]),
 			target:    row[1],
 			mType:     row[2],
-			data:      row[3:],
+			data:      data,
 		})
 	}
-	err = scanner.Err()
+	errs = append(errs, scanner.Err())
+	err = errors.Join(errs...)
+	return
+}
+
+// Entry is a single line of machine-readable output, as printed by vagrant commands run with --machine-readable.
+//
+// See https://www.vagrantup.com/docs/cli/machine-readable.html#format for more details.
+type Entry struct {
+	// Timestamp is the time at which vagrant printed the line. It is zero when the time is unavailable.
+	Timestamp time.Time
+	// Target is the machine the line refers to. It is empty for lines about the environment as a whole.
+	Target string
+	// Type is the kind of data on the line, e.g. "state", "ui" or "error-exit".
+	Type string
+	// Data holds the remaining fields with vagrant's escaping of commas and newlines reversed.
+	Data []string
+}
+
+// ParseMachineReadable parses the machine-readable output of a vagrant command, such as one run with
+// Vagrant.Run(..., "--machine-readable"), using the same rules as the rest of this package. Malformed lines are
+// skipped and reported together in the returned error, along with the entries that could be parsed.
+func ParseMachineReadable(out []byte) ([]Entry, error) {
+	parsed, err := parseMachineReadable(out)
+
+	var entries []Entry
+	for _, e := range parsed {
+		entries = append(entries, Entry{Timestamp: e.timestamp, Target: e.target, Type: e.mType, Data: e.data})
+	}
+	return entries, err
+}
+
+// parseTimestamp converts a Unix timestamp into a time.Time. The zero time is returned when the value is missing or
+// malformed so that a bad timestamp does not invalidate the rest of the entry.
+func parseTimestamp(value string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// MachineReadableError is returned when vagrant reports a failure through an "error-exit" or "error-single"
+// machine-readable entry, such as an invalid Vagrantfile, instead of or in addition to exiting with a non-zero status.
+type MachineReadableError struct {
+	// Class is the Ruby class of the vagrant error, e.g. "Vagrant::Errors::VagrantfileSyntaxError". It may be empty.
+	Class string
+	// Message is the human-readable error message.
+	Message string
+	// Err is the error returned by the command, if it also exited with a non-zero status.
+	Err error
+}
+
+func (e *MachineReadableError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error returned by the command, if any.
+func (e *MachineReadableError) Unwrap() error {
+	return e.Err
+}
+
+// machineReadableError returns a *MachineReadableError for the first error entry, or nil when there is none. cmdErr
+// is the error returned by the command and is attached to the result.
+func machineReadableError(entries []machineOutputEntry, cmdErr error) error {
+	for _, e := range entries {
+		if e.mType != "error-exit" && e.mType != "error-single" {
+			continue
+		}
+
+		mrErr := &MachineReadableError{Err: cmdErr}
+		switch len(e.data) {
+		case 0:
+			mrErr.Message = "vagrant reported an error without a message"
+		case 1:
+			mrErr.Message = strings.TrimSpace(e.data[0])
+		default:
+			mrErr.Class = e.data[0]
+			mrErr.Message = strings.TrimSpace(strings.Join(e.data[1:], ","))
+		}
+		return mrErr
+	}
+	return nil
+}
+
+// uiMessages returns the text of the human-readable "ui" entries, which vagrant prints for commands that have no
+// dedicated machine-readable output.
+func uiMessages(entries []machineOutputEntry) (messages []string) {
+	for _, e := range entries {
+		if e.mType == "ui" && len(e.data) > 1 {
+			messages = append(messages, strings.Join(e.data[1:], ","))
+		}
+	}
 	return
 }
 
@@ -1,9 +1,12 @@
 package vagrantexec
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMachineStateString(t *testing.T) {
@@ -11,23 +14,74 @@ func TestMachineStateString(t *testing.T) {
 		state MachineState
 		str   string
 	}{
-		{Unknown, "Unknown"},
-		{Aborted, "Aborted"},
-		{GuruMeditation, "GuruMeditation"},
-		{Inaccessible, "Inaccessible"},
-		{NotCreated, "NotCreated"},
-		{Paused, "Paused"},
-		{PowerOff, "PowerOff"},
-		{Stopping, "Stopping"},
-		{Running, "Running"},
-		{Saving, "Saving"},
-		{Saved, "Saved"},
-		{Stuck, "Stuck"},
+		{Unknown, "unknown"},
+		{Aborted, "aborted"},
+		{GuruMeditation, "gurumeditation"},
+		{Inaccessible, "inaccessible"},
+		{NotCreated, "not_created"},
+		{Paused, "paused"},
+		{PowerOff, "poweroff"},
+		{Stopping, "stopping"},
+		{Running, "running"},
+		{Saving, "saving"},
+		{Saved, "saved"},
+		{Stuck, "stuck"},
 	}
 
 	for _, tc := range testcases {
 		assert.Equal(t, tc.str, tc.state.String())
 	}
+
+	assert.Equal(t, "unknown", MachineState(99).String())
+}
+
+func TestMachineStateJSON(t *testing.T) {
+	t.Run("round_trip", func(t *testing.T) {
+		for state := Unknown; state <= Stuck; state++ {
+			bs, err := json.Marshal(state)
+			require.NoError(t, err)
+			assert.Equal(t, `"`+state.String()+`"`, string(bs))
+
+			var decoded MachineState
+			require.NoError(t, json.Unmarshal(bs, &decoded))
+			assert.Equal(t, state, decoded)
+		}
+	})
+
+	t.Run("provider_name", func(t *testing.T) {
+		var state MachineState
+		require.NoError(t, json.Unmarshal([]byte(`"shutoff"`), &state))
+		assert.Equal(t, PowerOff, state)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var state MachineState
+		assert.Error(t, json.Unmarshal([]byte(`8`), &state))
+	})
+}
+
+func TestMachineStatusJSON(t *testing.T) {
+	status := MachineStatus{
+		ID:          "5d6e7f8",
+		Name:        "web",
+		Provider:    "virtualbox",
+		State:       Running,
+		LastUpdated: time.Unix(1562176079, 0).UTC(),
+	}
+
+	bs, err := json.Marshal(status)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "5d6e7f8",
+		"name": "web",
+		"provider": "virtualbox",
+		"state": "running",
+		"last_updated": "2019-07-03T17:47:59Z"
+	}`, string(bs))
+
+	var decoded MachineStatus
+	require.NoError(t, json.Unmarshal(bs, &decoded))
+	assert.Equal(t, status, decoded)
 }
 
 func TestToMachineState(t *testing.T) {
@@ -53,6 +107,49 @@ func TestToMachineState(t *testing.T) {
 		state := ToMachineState(tc.str)
 		assert.Equalf(t, tc.state, state, "expected %s, got %s", tc.state, state)
 	}
+
+	providers := map[string][]struct {
+		str   string
+		state MachineState
+	}{
+		"docker": {
+			{"not_created", NotCreated},
+			{"running", Running},
+			{"stopped", PowerOff},
+			{"host_state_unknown", Unknown},
+		},
+		"libvirt": {
+			{"not_created", NotCreated},
+			{"running", Running},
+			{"paused", Paused},
+			{"shutdown", Stopping},
+			{"shutoff", PowerOff},
+			{"crashed", Aborted},
+			{"inaccessible", Inaccessible},
+		},
+		"hyperv": {
+			{"not_created", NotCreated},
+			{"running", Running},
+			{"off", PowerOff},
+			{"paused", Paused},
+			{"saved", Saved},
+			{"stopping", Stopping},
+		},
+		"vmware": {
+			{"not_created", NotCreated},
+			{"running", Running},
+			{"not_running", PowerOff},
+			{"suspended", Saved},
+		},
+	}
+
+	for provider, states := range providers {
+		t.Run(provider, func(t *testing.T) {
+			for _, tc := range states {
+				assert.Equalf(t, tc.state, ToMachineState(tc.str), "state %q", tc.str)
+			}
+		})
+	}
 }
 
 func TestMachineStatusIsRunning(t *testing.T) {
@@ -104,3 +201,82 @@ func TestMachineStatusIsRunnable(t *testing.T) {
 		assert.Equal(t, tc.expected, ms.IsRunnable())
 	}
 }
+
+func TestFilterStatuses(t *testing.T) {
+	statuses := []MachineStatus{
+		{Name: "web", State: Running},
+		{Name: "db", State: NotCreated},
+		{Name: "worker", State: PowerOff},
+		{Name: "cache", State: Running},
+	}
+
+	assert.Equal(t, []MachineStatus{statuses[0], statuses[3]}, FilterStatuses(statuses, Running))
+	assert.Equal(t, []MachineStatus{statuses[1], statuses[2]}, FilterStatuses(statuses, PowerOff, NotCreated))
+	assert.Empty(t, FilterStatuses(statuses, Saved))
+	assert.Empty(t, FilterStatuses(statuses))
+}
+
+func TestParseMachineReadable(t *testing.T) {
+	t.Run("timestamps", func(t *testing.T) {
+		out := []byte("1562176079,srv-1,state,running\n,srv-1,state,running\nbogus,srv-1,state,running\n")
+
+		entries, err := parseMachineReadable(out)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		assert.Equal(t, time.Unix(1562176079, 0), entries[0].timestamp)
+		assert.True(t, entries[1].timestamp.IsZero())
+		assert.True(t, entries[2].timestamp.IsZero())
+		assert.Equal(t, []string{"running"}, entries[2].data)
+	})
+
+	t.Run("escapes", func(t *testing.T) {
+		out := []byte(`1562176079,,ui,info,vagrant-disksize (0.1.3%!(VAGRANT_COMMA) global)\nsecond line` + "\n")
+
+		entries, err := parseMachineReadable(out)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		assert.Equal(t, []string{"info", "vagrant-disksize (0.1.3, global)\nsecond line"}, entries[0].data)
+	})
+
+	t.Run("field_counts", func(t *testing.T) {
+		out := []byte("\n1562176079,srv-1,state,running,extra\n  \n1562176079,srv-1,state\n1562176079,,ui,info\n")
+
+		entries, err := parseMachineReadable(out)
+		assert.EqualError(t, err, "invalid machine-readable format: [1562176079 srv-1 state]")
+		require.Len(t, entries, 2)
+
+		assert.Equal(t, []string{"running", "extra"}, entries[0].data)
+		assert.Equal(t, []string{"info"}, entries[1].data)
+		assert.Empty(t, uiMessages(entries))
+	})
+
+	t.Run("crlf", func(t *testing.T) {
+		out := []byte("1562176079,srv-1,provider-name,virtualbox\r\n1562176079,srv-1,state,running\r\n1562176080,srv-2,state,poweroff")
+
+		entries, err := parseMachineReadable(append(out, "\r"...))
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		assert.Equal(t, []string{"virtualbox"}, entries[0].data)
+		assert.Equal(t, []string{"running"}, entries[1].data)
+		assert.Equal(t, []string{"poweroff"}, entries[2].data)
+	})
+}
+
+func TestExportedParseMachineReadable(t *testing.T) {
+	out := []byte("1562175814,srv-1,state,running\n1562175815,,ui,info,one%!(VAGRANT_COMMA) two\\nthree\ngarbage\n")
+
+	entries, err := ParseMachineReadable(out)
+	assert.Error(t, err)
+	assert.Equal(t, []Entry{
+		{Timestamp: time.Unix(1562175814, 0), Target: "srv-1", Type: "state", Data: []string{"running"}},
+		{Timestamp: time.Unix(1562175815, 0), Type: "ui", Data: []string{"info", "one, two\nthree"}},
+	}, entries)
+}
+
+func TestStripANSI(t *testing.T) {
+	assert.Equal(t, "running (virtualbox)", string(stripANSI([]byte("\x1b[1;32mrunning\x1b[0m (\x1b[36mvirtualbox\x1b[0m)"))))
+	assert.Equal(t, "plain", string(stripANSI([]byte("plain"))))
+}
@@ -0,0 +1,113 @@
+package vagrantexec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// machineIndexTimeLayout is the format vagrant uses for timestamps in the machine index.
+const machineIndexTimeLayout = "2006-01-02 15:04:05 MST"
+
+// CombinedMachineStatus extends MachineStatus with data from Vagrant's machine index, the database behind
+// global-status.
+type CombinedMachineStatus struct {
+	MachineStatus
+	// Directory is the directory containing the Vagrantfile of the machine's environment.
+	Directory string
+	// IndexState is the state vagrant last recorded in the machine index, e.g. "running".
+	IndexState string
+	// IndexUpdatedAt is the time at which vagrant last updated the machine index entry, typically after the last
+	// action on the machine. It is zero when unknown.
+	IndexUpdatedAt time.Time
+}
+
+// machineIndex is the JSON document vagrant stores in data/machine-index/index under VAGRANT_HOME.
+type machineIndex struct {
+	Machines map[string]machineIndexEntry `json:"machines"`
+}
+
+// machineIndexEntry is a single machine in the machine index.
+type machineIndexEntry struct {
+	Name            string `json:"name"`
+	Provider        string `json:"provider"`
+	State           string `json:"state"`
+	VagrantfilePath string `json:"vagrantfile_path"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// CombinedStatus is like Status but adds the environment directory and the last recorded state from Vagrant's machine
+// index. When the index is missing or cannot be read, the Status data is returned without the index fields.
+func (w wrapper) CombinedStatus() ([]CombinedMachineStatus, error) {
+	return w.CombinedStatusContext(context.Background())
+}
+
+// CombinedStatusContext is like CombinedStatus but includes a context.
+func (w wrapper) CombinedStatusContext(ctx context.Context) ([]CombinedMachineStatus, error) {
+	statuses, err := w.StatusContext(ctx)
+	if err != nil && len(statuses) == 0 {
+		return nil, err
+	}
+
+	index, indexErr := readMachineIndex(w.vagrantHome())
+	if indexErr != nil {
+		w.logger.Debugf("Ignoring vagrant machine index: %v", indexErr)
+	}
+	dir, dirErr := w.vagrantfileDir()
+	if dirErr != nil {
+		w.logger.Debugf("Ignoring vagrant machine index: %v", dirErr)
+	}
+
+	combined := make([]CombinedMachineStatus, len(statuses))
+	for i, status := range statuses {
+		combined[i].MachineStatus = status
+		for _, entry := range index.Machines {
+			if entry.Name != status.Name || filepath.Clean(entry.VagrantfilePath) != dir {
+				continue
+			}
+			combined[i].Directory = entry.VagrantfilePath
+			combined[i].IndexState = entry.State
+			if t, err := time.Parse(machineIndexTimeLayout, entry.UpdatedAt); err == nil {
+				combined[i].IndexUpdatedAt = t
+			}
+			break
+		}
+	}
+	return combined, err
+}
+
+// vagrantHome returns the directory in which vagrant stores its global data, honoring VAGRANT_HOME.
+func (w wrapper) vagrantHome() string {
+	if home, ok := w.env["VAGRANT_HOME"]; ok {
+		return home
+	}
+	if home := os.Getenv("VAGRANT_HOME"); len(home) > 0 && !w.envOverride {
+		return home
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userHome, ".vagrant.d")
+}
+
+// readMachineIndex loads the machine index from a vagrant home directory.
+func readMachineIndex(vagrantHome string) (machineIndex, error) {
+	var index machineIndex
+	if len(vagrantHome) == 0 {
+		return index, errors.New("cannot determine vagrant home directory")
+	}
+
+	bs, err := os.ReadFile(filepath.Join(vagrantHome, "data", "machine-index", "index"))
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(bs, &index); err != nil {
+		return machineIndex{}, fmt.Errorf("invalid machine index: %w", err)
+	}
+	return index, nil
+}
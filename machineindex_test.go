@@ -0,0 +1,89 @@
+package vagrantexec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombinedStatus(t *testing.T) {
+	envDir, err := filepath.Abs("testdata/env")
+	require.NoError(t, err)
+
+	newCombined := func(t *testing.T, index string) wrapper {
+		home, err := ioutil.TempDir("", "vagrant-home")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(home) })
+		if len(index) > 0 {
+			indexDir := filepath.Join(home, "data", "machine-index")
+			require.NoError(t, os.MkdirAll(indexDir, 0755))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(indexDir, "index"), []byte(index), 0644))
+		}
+
+		w := mockedWrapperFn([]string{"status", "--machine-readable", "--no-color"})(ioutil.ReadFile("testdata/status-multiple"))
+		w.dir = envDir
+		w.env = map[string]string{"VAGRANT_HOME": home}
+		return w
+	}
+
+	t.Run("with_index", func(t *testing.T) {
+		index := fmt.Sprintf(`{"version":1,"machines":{
+			"5b0a8a7c1e9d4a0f8a1e2b3c4d5e6f70":{"name":"srv-1","provider":"virtualbox","state":"running",
+				"vagrantfile_path":%q,"updated_at":"2020-10-15 12:30:00 UTC"},
+			"0f9e8d7c6b5a49382716a5b4c3d2e1f0":{"name":"srv-1","provider":"virtualbox","state":"poweroff",
+				"vagrantfile_path":"/some/other/env","updated_at":"2020-10-14 08:00:00 UTC"}}}`, envDir)
+		w := newCombined(t, index)
+
+		statuses, err := w.CombinedStatus()
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+
+		assert.Equal(t, "srv-1", statuses[0].Name)
+		assert.Equal(t, Running, statuses[0].State)
+		assert.Equal(t, envDir, statuses[0].Directory)
+		assert.Equal(t, "running", statuses[0].IndexState)
+		assert.Equal(t, time.Date(2020, 10, 15, 12, 30, 0, 0, time.UTC), statuses[0].IndexUpdatedAt.UTC())
+
+		assert.Equal(t, "srv-2", statuses[1].Name)
+		assert.Empty(t, statuses[1].Directory)
+		assert.True(t, statuses[1].IndexUpdatedAt.IsZero())
+	})
+
+	t.Run("nested_dir", func(t *testing.T) {
+		index := fmt.Sprintf(`{"version":1,"machines":{
+			"5b0a8a7c1e9d4a0f8a1e2b3c4d5e6f70":{"name":"srv-1","provider":"virtualbox","state":"running",
+				"vagrantfile_path":%q,"updated_at":"2020-10-15 12:30:00 UTC"}}}`, envDir)
+		w := newCombined(t, index)
+		w.dir = filepath.Join(envDir, "nested")
+
+		statuses, err := w.CombinedStatus()
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.Equal(t, envDir, statuses[0].Directory)
+		assert.Equal(t, "running", statuses[0].IndexState)
+	})
+
+	t.Run("missing_index", func(t *testing.T) {
+		w := newCombined(t, "")
+
+		statuses, err := w.CombinedStatus()
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.Equal(t, PowerOff, statuses[1].State)
+		assert.Empty(t, statuses[0].Directory)
+	})
+
+	t.Run("corrupt_index", func(t *testing.T) {
+		w := newCombined(t, `{"machines": [`)
+
+		statuses, err := w.CombinedStatus()
+		require.NoError(t, err)
+		assert.Len(t, statuses, 2)
+	})
+}
@@ -0,0 +1,201 @@
+package vagrantexec
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+// Option configures optional behavior of the Vagrant wrapper returned by New.
+type Option func(*wrapper)
+
+// WithBinary sets the path to the vagrant executable. The default is to look up "vagrant" in the PATH.
+func WithBinary(path string) Option {
+	return func(w *wrapper) {
+		w.executable = path
+	}
+}
+
+// WithWorkingDir overrides the Vagrantfile directory given to New. Commands that operate on the environment fail with
+// a descriptive error when the directory does not exist or no Vagrantfile can be found in it or its parents.
+func WithWorkingDir(dir string) Option {
+	return func(w *wrapper) {
+		w.dir = dir
+	}
+}
+
+// WithVagrantfile points vagrant at a specific Vagrantfile, which may have any file name and live outside the
+// directory given to New, by setting VAGRANT_CWD to its directory and VAGRANT_VAGRANTFILE to its file name. The path
+// is resolved to an absolute path and also becomes the working directory. Commands that operate on the environment
+// fail with a descriptive error when the file does not exist.
+func WithVagrantfile(path string) Option {
+	return func(w *wrapper) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		w.vagrantfile = path
+		w.dir = filepath.Dir(path)
+		WithEnv(map[string]string{
+			"VAGRANT_CWD":         w.dir,
+			"VAGRANT_VAGRANTFILE": filepath.Base(path),
+		})(w)
+	}
+}
+
+// WithEnv sets environment variables such as VAGRANT_DEFAULT_PROVIDER or VAGRANT_LOG for every vagrant command. The
+// variables are merged into the environment of the current process and take precedence over it. Repeated calls add
+// to the previously configured variables.
+func WithEnv(vars map[string]string) Option {
+	return func(w *wrapper) {
+		if w.env == nil {
+			w.env = map[string]string{}
+		}
+		for k, v := range vars {
+			w.env[k] = v
+		}
+	}
+}
+
+// WithEnvOverride is like WithEnv but runs vagrant with only the configured variables instead of inheriting the
+// environment of the current process.
+func WithEnvOverride(vars map[string]string) Option {
+	return func(w *wrapper) {
+		WithEnv(vars)(w)
+		w.envOverride = true
+	}
+}
+
+// WithProvider makes every command default to the named provider, e.g. libvirt or docker, by setting
+// VAGRANT_DEFAULT_PROVIDER. A provider given for a single call, such as UpOptions.Provider, takes precedence, as does
+// a machine that already exists with another provider.
+func WithProvider(name string) Option {
+	return func(w *wrapper) {
+		WithEnv(map[string]string{"VAGRANT_DEFAULT_PROVIDER": name})(w)
+	}
+}
+
+// WithLogger replaces the default logrus logger with any implementation of Logger. The debug argument given to New has
+// no effect on a custom logger. A nil logger disables logging like WithQuiet.
+func WithLogger(logger Logger) Option {
+	return func(w *wrapper) {
+		if logger == nil {
+			logger = NopLogger{}
+		}
+		w.logger = logger
+	}
+}
+
+// WithQuiet disables all logging, for programs that handle vagrant output themselves, e.g. with WithOutput. Errors are
+// still returned as usual.
+func WithQuiet() Option {
+	return WithLogger(NopLogger{})
+}
+
+// WithSlogLogger routes log messages to a *slog.Logger instead of the default logrus logger. Command progress is
+// logged at slog.LevelInfo, the commands being run and their raw output at slog.LevelDebug and retries at
+// slog.LevelWarn. The debug argument given to New has no effect; configure the level on the logger's handler instead.
+func WithSlogLogger(logger *slog.Logger) Option {
+	return WithLogger(slogLogger{logger: logger})
+}
+
+// WithStructuredLogging logs every command as a single entry at Debug level once it completes, with the fields
+// command, args, duration, exit_code and output_bytes, instead of the free-text lines with the command and its raw
+// output. A logrus or slog logger receives them as fields; other loggers get them appended to the message.
+func WithStructuredLogging(enabled bool) Option {
+	return func(w *wrapper) {
+		w.structured = enabled
+	}
+}
+
+// WithDryRun logs every vagrant command at info level instead of running it. Commands succeed with no output, so
+// methods that parse output return empty results.
+func WithDryRun(enabled bool) Option {
+	return func(w *wrapper) {
+		w.dryRun = enabled
+	}
+}
+
+// WithCombinedOutput captures standard error along with standard output, in the order vagrant wrote them, for every
+// command. Methods that return output, such as SSH, then include both streams, and methods that log output, such as
+// Provision, log both. Output is still streamed to the writers configured with WithOutput as it is produced. Default
+// is to capture standard output only.
+func WithCombinedOutput(enabled bool) Option {
+	return func(w *wrapper) {
+		w.combined = enabled
+	}
+}
+
+// WithRedactedFlags adds command flags, such as --auth-token, whose values are replaced with "***" when commands and
+// their output are logged. The values of --token and --password, as well as passwords embedded in URLs, are always
+// redacted.
+func WithRedactedFlags(flags ...string) Option {
+	return func(w *wrapper) {
+		if w.redact == nil {
+			w.redact = map[string]bool{}
+		}
+		for _, flag := range flags {
+			w.redact[flag] = true
+		}
+	}
+}
+
+// WithSerializedExecution runs vagrant commands one at a time. Calls made while another command is running wait for it
+// to finish, or for their context to be cancelled. Long-running commands started by RSyncAuto, ShareStart and Connect
+// are not serialized since they would block every other command until cancelled.
+func WithSerializedExecution() Option {
+	return func(w *wrapper) {
+		w.serialize = make(chan struct{}, 1)
+	}
+}
+
+// WithAcceptedExitCodes treats the given non-zero exit codes of a vagrant subcommand, such as "validate" or
+// "box outdated", as success instead of returning an error. The codes apply to every command when subcommand is empty.
+// The option may be given several times, and codes for a subcommand add to those given for all commands.
+//
+// Commands whose result depends on their exit code map an accepted non-zero code to a boolean result:
+//
+//	box outdated  BoxOutdated reports the box as outdated
+//
+// Other commands simply return their output as if they had exited with status 0.
+func WithAcceptedExitCodes(subcommand string, codes ...int) Option {
+	return func(w *wrapper) {
+		if w.acceptedCodes == nil {
+			w.acceptedCodes = map[string][]int{}
+		}
+		subcommand = strings.Join(strings.Fields(subcommand), " ")
+		w.acceptedCodes[subcommand] = append(w.acceptedCodes[subcommand], codes...)
+	}
+}
+
+// WithRunner replaces the default command.ShellRunner used to execute vagrant commands. The runner is responsible
+// for executing commands in the Vagrantfile directory with the configured environment variables; the default runner
+// is set up with both automatically.
+func WithRunner(runner command.Runner) Option {
+	return func(w *wrapper) {
+		w.runner = runner
+	}
+}
+
+// WithOutput streams the standard output and standard error of every vagrant command to the given writers as it is
+// produced, which makes it possible to tail long-running operations like Up as they happen. Either writer may be nil.
+//
+// Command output is still captured and parsed as usual; it is simply no longer logged once it has been streamed.
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(w *wrapper) {
+		w.stdout = stdout
+		w.stderr = stderr
+	}
+}
+
+// WithStdin connects r to the standard input of vagrant commands, for instance to pipe a local script into the guest
+// with SSH("bash") instead of uploading it first. The reader is shared by every command the wrapper runs and can only
+// be consumed once, so use it with a wrapper created for a single command; a retried command finds it already read.
+// Commands read no input by default. Custom runners set with WithRunner are responsible for standard input themselves.
+func WithStdin(r io.Reader) Option {
+	return func(w *wrapper) {
+		w.stdin = r
+	}
+}
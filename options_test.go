@@ -0,0 +1,581 @@
+package vagrantexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBinary(t *testing.T) {
+	w := New(".", false, WithBinary("/usr/local/bin/vagrant-1.9")).(wrapper)
+	assert.Equal(t, "/usr/local/bin/vagrant-1.9", w.executable)
+}
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.record("DEBUG", format, args...)
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.record("INFO", format, args...)
+}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.record("WARN", format, args...)
+}
+
+func (l *recordingLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("logrus", func(t *testing.T) {
+		logger := logrus.New()
+		logger.SetLevel(logrus.WarnLevel)
+
+		w := New(".", true, WithLogger(logger)).(wrapper)
+		assert.Equal(t, logger, w.logger)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: []byte("Bringing machine 'default' up...")}, "vagrant", "up")
+		logger := &recordingLogger{}
+
+		w := New(".", false, WithLogger(logger), WithRunner(runner)).(wrapper)
+		w.dir = ""
+		require.NoError(t, w.Up())
+
+		assert.Equal(t, []string{
+			"INFO Starting vagrant environment",
+			"DEBUG Running command [vagrant up]",
+			"DEBUG Command output [vagrant up]: Bringing machine 'default' up...",
+			"INFO Bringing machine 'default' up...",
+		}, logger.lines)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		w := New(".", true, WithLogger(nil)).(wrapper)
+		assert.Equal(t, NopLogger{}, w.logger)
+	})
+}
+
+func TestWithQuiet(t *testing.T) {
+	runner := &command.MockRunner{}
+	runner.AddResponse(command.Response{Output: []byte("Bringing machine 'default' up...")}, "vagrant", "up")
+
+	w := New(".", true, WithQuiet(), WithStructuredLogging(true), WithRunner(runner)).(wrapper)
+	w.dir = ""
+	assert.Equal(t, NopLogger{}, w.logger)
+	assert.NoError(t, w.Up())
+}
+
+func TestWithSlogLogger(t *testing.T) {
+	runner := &command.MockRunner{}
+	runner.AddResponse(command.Response{Output: []byte("Bringing machine 'default' up...")}, "vagrant", "up")
+
+	t.Run("info", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+
+		w := New(".", true, WithSlogLogger(logger), WithRunner(runner)).(wrapper)
+		w.dir = ""
+		require.NoError(t, w.Up())
+
+		expected := "level=INFO msg=\"Starting vagrant environment\"\n" +
+			"level=INFO msg=\"Bringing machine 'default' up...\"\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		w := New(".", false, WithSlogLogger(logger), WithRunner(runner)).(wrapper)
+		w.dir = ""
+		require.NoError(t, w.Up())
+
+		assert.Contains(t, buf.String(), `level=DEBUG msg="Running command [vagrant up]"`)
+	})
+}
+
+func TestWithStructuredLogging(t *testing.T) {
+	runner := &command.MockRunner{}
+	runner.AddResponse(command.Response{Output: []byte("Bringing machine 'default' up...")}, "vagrant", "up")
+	runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "bad token s3cr3t")}, "vagrant", "cloud", "auth", "login", "--token", "s3cr3t")
+
+	t.Run("logrus", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logrus.New()
+		logger.SetLevel(logrus.DebugLevel)
+		logger.SetFormatter(&logrus.JSONFormatter{})
+		logger.Out = &buf
+
+		w := New(".", false, WithLogger(logger), WithRunner(runner), WithStructuredLogging(true)).(wrapper)
+		w.dir = ""
+		require.NoError(t, w.Up())
+
+		var entry map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			if entry["msg"] == "Command executed" {
+				break
+			}
+		}
+		assert.Equal(t, "Command executed", entry["msg"])
+		assert.Equal(t, "debug", entry["level"])
+		assert.Equal(t, "vagrant", entry["command"])
+		assert.Equal(t, []interface{}{"up"}, entry["args"])
+		assert.Equal(t, float64(0), entry["exit_code"])
+		assert.Equal(t, float64(32), entry["output_bytes"])
+		assert.Contains(t, entry, "duration")
+		assert.NotContains(t, buf.String(), "Running command")
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		w := New(".", false, WithSlogLogger(logger), WithRunner(runner), WithStructuredLogging(true)).(wrapper)
+		w.dir = ""
+		require.NoError(t, w.Up())
+
+		assert.Contains(t, buf.String(), `level=DEBUG msg="Command executed" args=[up] command=vagrant duration=`)
+		assert.Contains(t, buf.String(), `exit_code=0 output_bytes=32`)
+	})
+
+	t.Run("plain_logger", func(t *testing.T) {
+		logger := &recordingLogger{}
+		w := wrapper{executable: binary, runner: runner, logger: logger, structured: true}
+
+		assert.Error(t, w.CloudLogin("s3cr3t"))
+		require.Len(t, logger.lines, 2)
+		assert.Regexp(t, `^DEBUG Command executed args=\[cloud auth login --token \*\*\*\] command=vagrant duration=\S+ `+
+			`error=vagrant exited with status 1: bad token \*\*\* exit_code=1 output_bytes=0$`, logger.lines[1])
+		for _, line := range logger.lines {
+			assert.NotContains(t, line, "s3cr3t")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		logger := &recordingLogger{}
+		w := wrapper{executable: binary, runner: runner, logger: logger}
+
+		require.NoError(t, w.Up())
+		assert.Contains(t, logger.lines, "DEBUG Running command [vagrant up]")
+	})
+}
+
+func TestWithRedactedFlags(t *testing.T) {
+	w := New(".", false, WithRedactedFlags("--auth-token"), WithRedactedFlags("--secret")).(wrapper)
+
+	assert.Equal(t, map[string]bool{"--auth-token": true, "--secret": true}, w.redact)
+	assert.Equal(t, "vagrant up --secret *** --token ***", w.commandLine([]string{"up", "--secret", "a", "--token", "b"}))
+}
+
+// concurrencyRunner records the highest number of commands that ran at the same time.
+type concurrencyRunner struct {
+	command.MockRunner
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (r *concurrencyRunner) ExecuteContext(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return r.MockRunner.ExecuteContext(ctx, cmd, args...)
+}
+
+func TestWithSerializedExecution(t *testing.T) {
+	t.Run("one_at_a_time", func(t *testing.T) {
+		runner := &concurrencyRunner{}
+		w := New(".", false, WithSerializedExecution(), WithRunner(runner), WithLogger(&recordingLogger{})).(wrapper)
+		w.dir = ""
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, w.Up())
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, runner.max)
+		assert.Len(t, runner.Calls(), 5)
+	})
+
+	t.Run("cancelled_while_waiting", func(t *testing.T) {
+		w := New(".", false, WithSerializedExecution(), WithRunner(&command.MockRunner{}), WithLogger(&recordingLogger{})).(wrapper)
+		w.dir = ""
+		w.serialize <- struct{}{}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := w.UpContext(ctx)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		runner := &concurrencyRunner{}
+		w := New(".", false, WithRunner(runner), WithLogger(&recordingLogger{})).(wrapper)
+		w.dir = ""
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, w.Halt())
+			}()
+		}
+		wg.Wait()
+
+		assert.True(t, runner.max > 1)
+	})
+}
+
+func TestWithRunner(t *testing.T) {
+	runner := command.ShellRunner{Dir: "/other/path"}
+
+	w := New(".", false, WithRunner(runner)).(wrapper)
+	assert.Equal(t, runner, w.runner)
+}
+
+func TestWithOutput(t *testing.T) {
+	newStreamingWrapper := func(out []byte, err error, stdout, stderr *bytes.Buffer) wrapper {
+		runner := new(mockRunner)
+		runner.On("ExecuteStream", mock.Anything, mock.Anything, mock.Anything, "vagrant", []string{"up"}).Return(out, err)
+
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+
+		w := wrapper{
+			executable: binary,
+			logger:     logger,
+			runner:     runner,
+		}
+		WithOutput(stdout, stderr)(&w)
+		return w
+	}
+
+	t.Run("streams_output", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		w := newStreamingWrapper([]byte("up output"), nil, &stdout, &stderr)
+
+		require.NoError(t, w.Up())
+		assert.Equal(t, "up output", stdout.String())
+	})
+
+	t.Run("captures_output", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := newStreamingWrapper([]byte("up output"), nil, &stdout, nil)
+
+		out, err := w.exec(context.Background(), "up")
+		require.NoError(t, err)
+		assert.Equal(t, "up output", string(out))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := newStreamingWrapper(nil, errors.New("up failed"), &stdout, nil)
+
+		assert.Error(t, w.Up())
+	})
+}
+
+func TestWithCombinedOutput(t *testing.T) {
+	runner := new(mockRunner)
+	runner.On("ExecuteStream", mock.Anything, mock.Anything, mock.Anything, "vagrant", []string{"ssh", "--no-tty", "--command", "make"}).
+		Run(func(args mock.Arguments) {
+			args.Get(2).(io.Writer).Write([]byte("warning: clock skew detected\n"))
+		}).
+		Return([]byte("build complete\n"), nil)
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	w := wrapper{
+		executable: binary,
+		logger:     logger,
+		runner:     runner,
+	}
+	WithCombinedOutput(true)(&w)
+
+	out, err := w.SSH("", "make")
+	require.NoError(t, err)
+	assert.Equal(t, "warning: clock skew detected\nbuild complete\n", out)
+}
+
+func TestWithDryRun(t *testing.T) {
+	newDryRun := func() (wrapper, *recordingLogger, *mockRunner) {
+		logger := &recordingLogger{}
+		runner := &mockRunner{}
+		w := New(".", false, WithDryRun(true), WithLogger(logger), WithRunner(runner)).(wrapper)
+		w.dir = ""
+		return w, logger, runner
+	}
+
+	t.Run("logs_command", func(t *testing.T) {
+		w, logger, runner := newDryRun()
+
+		require.NoError(t, w.Up("web", "db"))
+		assert.Contains(t, logger.lines, "INFO Dry run, not running command [vagrant up web db]")
+		runner.AssertNotCalled(t, "ExecuteContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty_results", func(t *testing.T) {
+		w, _, runner := newDryRun()
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+		assert.Empty(t, statuses)
+
+		version, err := w.Version()
+		require.NoError(t, err)
+		assert.Empty(t, version)
+
+		plugins, err := w.PluginList()
+		require.NoError(t, err)
+		assert.Empty(t, plugins)
+
+		boxes, err := w.BoxList()
+		require.NoError(t, err)
+		assert.Empty(t, boxes)
+
+		info, err := w.SSHConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, &SSHInfo{}, info)
+
+		runner.AssertNotCalled(t, "ExecuteContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		w := New(".", false, WithDryRun(false)).(wrapper)
+		assert.False(t, w.dryRun)
+	})
+}
+
+func TestWithWorkingDir(t *testing.T) {
+	w := New("/some/path", false, WithWorkingDir("testdata/env")).(wrapper)
+	assert.Equal(t, "testdata/env", w.dir)
+	assert.Equal(t, "testdata/env", w.runner.(command.ShellRunner).Dir)
+
+	t.Run("vagrantfile_found", func(t *testing.T) {
+		for _, dir := range []string{"testdata/env", "testdata/env/nested"} {
+			w := mockedWrapperFn([]string{"up"})(nil, nil)
+			WithWorkingDir(dir)(&w)
+
+			assert.NoError(t, w.Up(), "dir %s", dir)
+		}
+	})
+
+	t.Run("missing_dir", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		WithWorkingDir("testdata/does-not-exist")(&w)
+
+		err := w.Up()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid working directory")
+	})
+
+	t.Run("missing_vagrantfile", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "vagrant-exec")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		WithWorkingDir(dir)(&w)
+
+		err = w.Up()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no Vagrantfile found in")
+		assert.True(t, IsVagrantfileNotFound(err))
+	})
+
+	t.Run("global_command", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"version", "--machine-readable", "--no-color"})(ioutil.ReadFile("testdata/version"))
+		WithWorkingDir("testdata/does-not-exist")(&w)
+
+		_, err := w.Version()
+		assert.NoError(t, err)
+	})
+}
+
+func TestWithProvider(t *testing.T) {
+	w := New(".", false, WithEnv(map[string]string{"VAGRANT_LOG": "info"}), WithProvider("libvirt")).(wrapper)
+	assert.Equal(t, map[string]string{"VAGRANT_LOG": "info", "VAGRANT_DEFAULT_PROVIDER": "libvirt"}, w.env)
+
+	t.Run("call_level_provider", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up", "--provider", "docker"})(nil, nil)
+		WithProvider("libvirt")(&w)
+
+		assert.NoError(t, w.UpWithOptions(UpOptions{Provider: "docker"}))
+		assert.Equal(t, "libvirt", w.env["VAGRANT_DEFAULT_PROVIDER"])
+	})
+}
+
+func TestWithVagrantfile(t *testing.T) {
+	abs, err := filepath.Abs("testdata/env/Vagrantfile")
+	require.NoError(t, err)
+
+	w := New(".", false, WithVagrantfile("testdata/env/Vagrantfile")).(wrapper)
+	assert.Equal(t, abs, w.vagrantfile)
+	assert.Equal(t, filepath.Dir(abs), w.dir)
+
+	r := w.runner.(command.ShellRunner)
+	assert.Equal(t, filepath.Dir(abs), r.Dir)
+	assert.Equal(t, []string{"VAGRANT_CWD=" + filepath.Dir(abs), "VAGRANT_VAGRANTFILE=Vagrantfile"}, r.Env)
+
+	t.Run("custom_name", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "vagrant-exec")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "Vagrantfile.ci")
+		require.NoError(t, ioutil.WriteFile(path, []byte("Vagrant.configure(\"2\") do |config|\nend\n"), 0644))
+
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		WithVagrantfile(path)(&w)
+
+		assert.NoError(t, w.Up())
+		assert.Equal(t, "Vagrantfile.ci", w.env["VAGRANT_VAGRANTFILE"])
+	})
+
+	t.Run("missing_vagrantfile", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		WithVagrantfile("testdata/env/Vagrantfile.missing")(&w)
+
+		err := w.Up()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid Vagrantfile")
+		assert.True(t, IsVagrantfileNotFound(err))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		WithVagrantfile("testdata/env")(&w)
+
+		err := w.Up()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is a directory")
+	})
+}
+
+func TestWithEnv(t *testing.T) {
+	t.Run("merge", func(t *testing.T) {
+		w := New(".", false,
+			WithEnv(map[string]string{"VAGRANT_LOG": "debug"}),
+			WithEnv(map[string]string{"VAGRANT_DEFAULT_PROVIDER": "libvirt"}),
+		).(wrapper)
+
+		r := w.runner.(command.ShellRunner)
+		assert.Equal(t, []string{"VAGRANT_DEFAULT_PROVIDER=libvirt", "VAGRANT_LOG=debug"}, r.Env)
+		assert.False(t, r.OverrideEnv)
+	})
+
+	t.Run("override", func(t *testing.T) {
+		w := New(".", false, WithEnvOverride(map[string]string{"HOME": "/tmp"})).(wrapper)
+
+		r := w.runner.(command.ShellRunner)
+		assert.Equal(t, []string{"HOME=/tmp"}, r.Env)
+		assert.True(t, r.OverrideEnv)
+	})
+}
+
+func TestWithStdin(t *testing.T) {
+	stdin := strings.NewReader("echo hello")
+	w := New(".", false, WithStdin(stdin)).(wrapper)
+
+	assert.Equal(t, stdin, w.runner.(command.ShellRunner).Stdin)
+	assert.Equal(t, stdin, w.Environment("testdata/env").(wrapper).runner.(command.ShellRunner).Stdin)
+	assert.Nil(t, New(".", false).(wrapper).runner.(command.ShellRunner).Stdin)
+}
+
+func TestWithAcceptedExitCodes(t *testing.T) {
+	newAccepting := func(opts ...Option) (wrapper, *command.MockRunner) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 2, "")}, "vagrant", "box", "prune")
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 3, "")}, "vagrant", "box", "repackage")
+		w := New(".", false, append([]Option{WithRunner(runner), WithLogger(&recordingLogger{})}, opts...)...).(wrapper)
+		return w, runner
+	}
+
+	t.Run("subcommand", func(t *testing.T) {
+		w, _ := newAccepting(WithAcceptedExitCodes(" box  prune ", 2))
+
+		out, code, err := w.execExitCode(context.Background(), "box", "prune")
+		require.NoError(t, err)
+		assert.Empty(t, out)
+		assert.Equal(t, 2, code)
+
+		_, err = w.exec(context.Background(), "box", "repackage")
+		assert.Error(t, err)
+	})
+
+	t.Run("all_commands", func(t *testing.T) {
+		w, _ := newAccepting(WithAcceptedExitCodes("", 2), WithAcceptedExitCodes("", 3))
+
+		_, err := w.exec(context.Background(), "box", "prune")
+		assert.NoError(t, err)
+		_, err = w.exec(context.Background(), "box", "repackage")
+		assert.NoError(t, err)
+	})
+
+	t.Run("other_codes", func(t *testing.T) {
+		w, _ := newAccepting(WithAcceptedExitCodes("box", 4))
+
+		_, code, err := w.execExitCode(context.Background(), "box", "prune")
+		assert.Error(t, err)
+		assert.Zero(t, code)
+	})
+
+	t.Run("subcommand_prefix", func(t *testing.T) {
+		// "box p" must not match "box prune"
+		w, _ := newAccepting(WithAcceptedExitCodes("box p", 2))
+
+		_, err := w.exec(context.Background(), "box", "prune")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,97 @@
+package vagrantexec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultPackageOutput is the file vagrant writes the box to when no output is given.
+const defaultPackageOutput = "package.box"
+
+// PackageOptions configures the behavior of Vagrant.Package.
+type PackageOptions struct {
+	// Output is the path of the box file to create. Relative paths are resolved against the Vagrantfile directory.
+	// Defaults to package.box.
+	Output string
+	// Base packages a VirtualBox machine with this name instead of a machine in the environment.
+	Base string
+	// Vagrantfile is a Vagrantfile to include in the box.
+	Vagrantfile string
+	// Include lists additional files to include in the box.
+	Include []string
+	// Machine limits packaging to the named machine. It can be empty if you only have one VM defined.
+	Machine string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// Package exports a machine as a reusable box file.
+func (w wrapper) Package(opts PackageOptions) error {
+	return w.PackageContext(context.Background(), opts)
+}
+
+// PackageContext is like Package but includes a context.
+func (w wrapper) PackageContext(ctx context.Context, opts PackageOptions) error {
+	cmdArgs := []string{"package"}
+
+	output := opts.Output
+	if len(output) == 0 {
+		output = defaultPackageOutput
+	} else {
+		cmdArgs = append(cmdArgs, "--output", output)
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(w.dir, output)
+	}
+	if err := checkWritableDir(filepath.Dir(output)); err != nil {
+		return fmt.Errorf("cannot write box to %s: %w", output, err)
+	}
+
+	if len(opts.Base) > 0 {
+		cmdArgs = append(cmdArgs, "--base", opts.Base)
+	}
+	if len(opts.Vagrantfile) > 0 {
+		cmdArgs = append(cmdArgs, "--vagrantfile", opts.Vagrantfile)
+	}
+	if len(opts.Include) > 0 {
+		cmdArgs = append(cmdArgs, "--include", strings.Join(opts.Include, ","))
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	if len(opts.Machine) > 0 {
+		if err := validateMachineNames([]string{opts.Machine}); err != nil {
+			return err
+		}
+		cmdArgs = append(cmdArgs, opts.Machine)
+	}
+
+	w.logger.Infof("Packaging vagrant machine")
+	if err := w.execLogOutput(ctx, cmdArgs...); err != nil {
+		return err
+	}
+	w.logger.Infof("Packaged vagrant box: %s", output)
+	return nil
+}
+
+// checkWritableDir verifies that dir is an existing directory in which files can be created.
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".vagrant-exec-")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
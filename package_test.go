@@ -0,0 +1,63 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vagrant-exec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Vagrantfile"), nil, 0644))
+
+	output := filepath.Join(dir, "my.box")
+	mockPackage := mockedWrapperFn([]string{"package", "--output", output})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockPackage([]byte("package output"), nil)
+		assert.NoError(t, w.Package(PackageOptions{Output: output}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPackage(nil, errors.New("runner error"))
+		assert.Error(t, w.Package(PackageOptions{Output: output}))
+	})
+
+	t.Run("default_output", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"package"})(nil, nil)
+		w.dir = dir
+
+		assert.NoError(t, w.Package(PackageOptions{}))
+	})
+
+	t.Run("all_options", func(t *testing.T) {
+		w := mockedWrapperFn([]string{
+			"package", "--output", output, "--base", "my-vm", "--vagrantfile", "Vagrantfile.pkg",
+			"--include", "README.md,setup.sh", "web",
+		})(nil, nil)
+
+		opts := PackageOptions{
+			Output:      output,
+			Base:        "my-vm",
+			Vagrantfile: "Vagrantfile.pkg",
+			Include:     []string{"README.md", "setup.sh"},
+			Machine:     "web",
+		}
+		assert.NoError(t, w.Package(opts))
+	})
+
+	t.Run("missing_output_dir", func(t *testing.T) {
+		w := mockPackage(nil, nil)
+
+		err := w.Package(PackageOptions{Output: filepath.Join(dir, "missing", "my.box")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot write box to")
+	})
+}
@@ -0,0 +1,82 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Step is a named operation run by a Pipeline.
+type Step struct {
+	// Name identifies the step in log messages and errors.
+	Name string
+	// Run performs the step, typically by calling one or more methods of v.
+	Run func(ctx context.Context, v Vagrant) error
+}
+
+// StepError is returned by Pipeline.Run for a step that failed.
+type StepError struct {
+	// Step is the name of the step.
+	Step string
+	// Cleanup is true for a cleanup step.
+	Cleanup bool
+	// Err is the error returned by the step, or the context error for a step that was not started because the context
+	// was done.
+	Err error
+}
+
+func (e *StepError) Error() string {
+	if e.Cleanup {
+		return fmt.Sprintf("cleanup step %s failed: %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+// Unwrap returns the error of the step.
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Pipeline runs a sequence of steps against a Vagrant environment, such as up, provision, a smoke test over SSH and
+// halt, with cleanup steps like destroy that run whatever the outcome.
+type Pipeline struct {
+	// Steps run in order until one of them fails or the context is done.
+	Steps []Step
+	// Cleanup steps always run once Steps are done, in reverse order like deferred calls, even when a step failed or
+	// the context was cancelled. They receive a context that carries the values of the original one but is never
+	// cancelled, so that e.g. a destroy is not cut short; bound them with their own timeout if needed. A failed
+	// cleanup step does not prevent the others from running.
+	Cleanup []Step
+}
+
+// Run runs the pipeline with v, logging each step with the logger of v when it was created by New. The error of the
+// failed step and those of failed cleanup steps are returned together as *StepError values.
+func (p Pipeline) Run(ctx context.Context, v Vagrant) error {
+	logger := Logger(NopLogger{})
+	if w, ok := v.(wrapper); ok && w.logger != nil {
+		logger = w.logger
+	}
+
+	var errs []error
+	for i, step := range p.Steps {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, &StepError{Step: step.Name, Err: err})
+			break
+		}
+		logger.Infof("Pipeline step %d/%d: %s", i+1, len(p.Steps), step.Name)
+		if err := step.Run(ctx, v); err != nil {
+			errs = append(errs, &StepError{Step: step.Name, Err: err})
+			break
+		}
+	}
+
+	cleanupCtx := context.WithoutCancel(ctx)
+	for i := len(p.Cleanup) - 1; i >= 0; i-- {
+		step := p.Cleanup[i]
+		logger.Infof("Pipeline cleanup: %s", step.Name)
+		if err := step.Run(cleanupCtx, v); err != nil {
+			errs = append(errs, &StepError{Step: step.Name, Cleanup: true, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
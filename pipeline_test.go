@@ -0,0 +1,81 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline(t *testing.T) {
+	newPipeline := func() (wrapper, *command.MockRunner, *recordingLogger) {
+		runner := &command.MockRunner{}
+		logger := &recordingLogger{}
+		return wrapper{executable: binary, runner: runner, logger: logger}, runner, logger
+	}
+	up := Step{Name: "up", Run: func(ctx context.Context, v Vagrant) error { return v.UpContext(ctx) }}
+	halt := Step{Name: "halt", Run: func(ctx context.Context, v Vagrant) error { return v.HaltContext(ctx) }}
+	destroy := Step{Name: "destroy", Run: func(ctx context.Context, v Vagrant) error { return v.DestroyContext(ctx) }}
+
+	t.Run("success", func(t *testing.T) {
+		w, runner, logger := newPipeline()
+		p := Pipeline{Steps: []Step{up, halt}, Cleanup: []Step{destroy}}
+
+		require.NoError(t, p.Run(context.Background(), w))
+		calls := runner.Calls()
+		require.Len(t, calls, 3)
+		assert.Equal(t, "vagrant up", calls[0].String())
+		assert.Equal(t, "vagrant halt", calls[1].String())
+		assert.Equal(t, "vagrant destroy --force", calls[2].String())
+		assert.Contains(t, logger.lines, "INFO Pipeline step 2/2: halt")
+		assert.Contains(t, logger.lines, "INFO Pipeline cleanup: destroy")
+	})
+
+	t.Run("stops_on_error", func(t *testing.T) {
+		w, runner, _ := newPipeline()
+		runner.AddResponse(command.Response{Err: errors.New("up failed")}, "vagrant", "up")
+		p := Pipeline{Steps: []Step{up, halt}, Cleanup: []Step{destroy}}
+
+		err := p.Run(context.Background(), w)
+		assert.EqualError(t, err, "step up failed: up failed")
+		var se *StepError
+		require.True(t, errors.As(err, &se))
+		assert.Equal(t, "up", se.Step)
+
+		calls := runner.Calls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "vagrant destroy --force", calls[1].String())
+	})
+
+	t.Run("cleanup_errors", func(t *testing.T) {
+		w, runner, _ := newPipeline()
+		runner.AddResponse(command.Response{Err: errors.New("halt failed")}, "vagrant", "halt")
+		runner.AddResponse(command.Response{Err: errors.New("destroy failed")}, "vagrant", "destroy", "--force")
+		p := Pipeline{Cleanup: []Step{destroy, halt}}
+
+		err := p.Run(context.Background(), w)
+		assert.EqualError(t, err, "cleanup step halt failed: halt failed\ncleanup step destroy failed: destroy failed")
+		assert.Len(t, runner.Calls(), 2)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		w, runner, _ := newPipeline()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelling := Step{Name: "cancel", Run: func(context.Context, Vagrant) error {
+			cancel()
+			return nil
+		}}
+		p := Pipeline{Steps: []Step{cancelling, up}, Cleanup: []Step{destroy}}
+
+		err := p.Run(ctx, w)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.EqualError(t, err, "step up failed: context canceled")
+
+		calls := runner.Calls()
+		require.Len(t, calls, 1)
+		assert.Equal(t, "vagrant destroy --force", calls[0].String())
+	})
+}
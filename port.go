@@ -0,0 +1,60 @@
+package vagrantexec
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// PortMapping describes a guest port that is forwarded to a port on the host.
+type PortMapping struct {
+	Guest int
+	Host  int
+}
+
+// Port returns the forwarded port mappings of a machine. You can use an empty string as the machine if you only have
+// one VM defined in your Vagrantfile. An empty slice is returned when the machine has no forwarded ports.
+func (w wrapper) Port(machine string) ([]PortMapping, error) {
+	return w.PortContext(context.Background(), machine)
+}
+
+// PortContext is like Port but includes a context.
+func (w wrapper) PortContext(ctx context.Context, machine string) ([]PortMapping, error) {
+	cmdArgs := []string{"port", "--machine-readable", "--no-color"}
+	if len(machine) > 0 {
+		if err := validateMachineNames([]string{machine}); err != nil {
+			return nil, err
+		}
+		cmdArgs = append(cmdArgs, machine)
+	}
+
+	out, err := w.exec(ctx, cmdArgs...)
+	if err != nil {
+		return nil, err
+	}
+	portInfo, err := parseMachineReadable(out)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := []PortMapping{}
+	for _, entry := range portInfo {
+		if entry.mType != "forwarded_port" {
+			continue
+		}
+		if len(entry.data) < 2 {
+			return nil, fmt.Errorf("invalid forwarded_port entry: %s", entry.data)
+		}
+
+		guest, err := strconv.Atoi(entry.data[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid guest port %q: %w", entry.data[0], err)
+		}
+		host, err := strconv.Atoi(entry.data[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid host port %q: %w", entry.data[1], err)
+		}
+		mappings = append(mappings, PortMapping{Guest: guest, Host: host})
+	}
+	return mappings, nil
+}
@@ -0,0 +1,59 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPort(t *testing.T) {
+	mockPort := mockedWrapperFn([]string{"port", "--machine-readable", "--no-color"})
+
+	t.Run("with_ports", func(t *testing.T) {
+		w := mockPort(ioutil.ReadFile("testdata/port"))
+
+		mappings, err := w.Port("")
+		require.NoError(t, err)
+
+		expected := []PortMapping{
+			{Guest: 22, Host: 2222},
+			{Guest: 80, Host: 8080},
+			{Guest: 5432, Host: 15432},
+		}
+		assert.Equal(t, expected, mappings)
+	})
+
+	t.Run("no_ports", func(t *testing.T) {
+		w := mockPort(ioutil.ReadFile("testdata/port-none"))
+
+		mappings, err := w.Port("")
+		require.NoError(t, err)
+		assert.NotNil(t, mappings)
+		assert.Empty(t, mappings)
+	})
+
+	t.Run("named_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"port", "--machine-readable", "--no-color", "web"})(ioutil.ReadFile("testdata/port"))
+
+		mappings, err := w.Port("web")
+		require.NoError(t, err)
+		assert.Len(t, mappings, 3)
+	})
+
+	t.Run("bad_port", func(t *testing.T) {
+		w := mockPort([]byte("1563218345,default,forwarded_port,22,abc"), nil)
+
+		_, err := w.Port("")
+		assert.Error(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPort(nil, errors.New("runner error"))
+
+		_, err := w.Port("")
+		assert.Error(t, err)
+	})
+}
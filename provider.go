@@ -0,0 +1,75 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+// builtinProviders are the providers that ship with vagrant.
+var builtinProviders = []string{"virtualbox", "docker", "hyperv"}
+
+// pluginProviders maps the plugins that add a provider to the name of that provider.
+var pluginProviders = map[string]string{
+	"vagrant-libvirt":        "libvirt",
+	"vagrant-vmware-desktop": "vmware_desktop",
+	"vagrant-parallels":      "parallels",
+	"vagrant-lxc":            "lxc",
+	"vagrant-qemu":           "qemu",
+}
+
+// providerNotUsablePattern matches the error vagrant prints when the provider of a machine cannot be used on the host,
+// e.g. because the hypervisor is not installed.
+var providerNotUsablePattern = regexp.MustCompile(`(?s)The provider '[^']+' that was requested to back the machine\s+'[^']+' is reporting that it isn't usable on this system`)
+
+// AvailableProviders returns the providers vagrant can use on this host: the built-in virtualbox, docker and hyperv
+// providers, in that order, followed by those added by installed plugins such as vagrant-libvirt and
+// vagrant-vmware-desktop. Built-in providers are always listed, whether or not the software they drive is installed;
+// use ProviderUsable to check that the provider of a machine actually works.
+func (w wrapper) AvailableProviders() ([]string, error) {
+	return w.AvailableProvidersContext(context.Background())
+}
+
+// AvailableProvidersContext is like AvailableProviders but includes a context.
+func (w wrapper) AvailableProvidersContext(ctx context.Context) ([]string, error) {
+	plugins, err := w.PluginListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := append([]string{}, builtinProviders...)
+	for _, plugin := range plugins {
+		if provider, ok := pluginProviders[plugin.Name]; ok {
+			providers = append(providers, provider)
+		}
+	}
+	return providers, nil
+}
+
+// ProviderUsable reports whether the provider of a machine can be used on this host, which allows failing early with
+// a helpful message when e.g. the hypervisor is not installed. You can use an empty string as the machine if you only
+// have one VM defined in your Vagrantfile.
+func (w wrapper) ProviderUsable(machine string) (bool, error) {
+	return w.ProviderUsableContext(context.Background(), machine)
+}
+
+// ProviderUsableContext is like ProviderUsable but includes a context.
+func (w wrapper) ProviderUsableContext(ctx context.Context, machine string) (bool, error) {
+	cmdArgs := []string{"provider", "--usable"}
+	if len(machine) > 0 {
+		if err := validateMachineNames([]string{machine}); err != nil {
+			return false, err
+		}
+		cmdArgs = append(cmdArgs, machine)
+	}
+
+	_, err := w.exec(ctx, cmdArgs...)
+	var ee command.ExitError
+	if errors.As(err, &ee) && providerNotUsablePattern.MatchString(ee.Stderr()) {
+		w.logger.Debugf("Provider is not usable: %s", ee.Stderr())
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,91 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailableProviders(t *testing.T) {
+	mockPluginList := mockedWrapperFn([]string{"plugin", "list", "--machine-readable", "--no-color"})
+
+	t.Run("plugins", func(t *testing.T) {
+		w := mockPluginList(ioutil.ReadFile("testdata/plugin-list-providers"))
+
+		providers, err := w.AvailableProviders()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"virtualbox", "docker", "hyperv", "libvirt", "vmware_desktop"}, providers)
+	})
+
+	t.Run("builtin_only", func(t *testing.T) {
+		w := mockPluginList(ioutil.ReadFile("testdata/plugin-list"))
+
+		providers, err := w.AvailableProviders()
+		require.NoError(t, err)
+		assert.Equal(t, builtinProviders, providers)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPluginList(nil, errors.New("runner error"))
+
+		_, err := w.AvailableProviders()
+		assert.EqualError(t, err, "runner error")
+	})
+
+	t.Run("no_vagrantfile_required", func(t *testing.T) {
+		w := mockPluginList(ioutil.ReadFile("testdata/plugin-list-providers"))
+		w.dir = "testdata/missing"
+
+		_, err := w.AvailableProviders()
+		assert.NoError(t, err)
+	})
+}
+
+func TestProviderUsable(t *testing.T) {
+	notUsable, err := ioutil.ReadFile("testdata/provider-not-usable")
+	require.NoError(t, err)
+
+	t.Run("usable", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"provider", "--usable"})(nil, nil)
+
+		usable, err := w.ProviderUsable("")
+		require.NoError(t, err)
+		assert.True(t, usable)
+	})
+
+	t.Run("not_usable", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"provider", "--usable", "web"})(nil, command.NewExitError("vagrant", 1, string(notUsable)))
+
+		usable, err := w.ProviderUsable("web")
+		require.NoError(t, err)
+		assert.False(t, usable)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		stderr := "The machine with the name 'db' was not found configured for\nthis Vagrant environment."
+		w := mockedWrapperFn([]string{"provider", "--usable", "db"})(nil, command.NewExitError("vagrant", 1, stderr))
+
+		usable, err := w.ProviderUsable("db")
+		assert.Error(t, err)
+		assert.False(t, usable)
+	})
+
+	t.Run("requires_vagrantfile", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"provider", "--usable"})(nil, nil)
+		w.dir = "testdata/missing"
+
+		_, err := w.ProviderUsable("")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"provider", "--usable"})(nil, nil)
+
+		_, err := w.ProviderUsable("web;ls")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+package vagrantexec
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// redactedValue replaces secrets in log messages.
+const redactedValue = "***"
+
+// defaultRedactedFlags are command flags whose values are always treated as secrets.
+var defaultRedactedFlags = []string{"--token", "--password"}
+
+// isRedactedFlag returns true if the value of the flag must not be logged.
+func (w wrapper) isRedactedFlag(flag string) bool {
+	for _, f := range defaultRedactedFlags {
+		if f == flag {
+			return true
+		}
+	}
+	return w.redact[flag]
+}
+
+// redactArgs replaces the values of redacted flags, given either as "--flag value" or "--flag=value", and the
+// passwords of URLs with "***". The replaced values are returned as secrets.
+func (w wrapper) redactArgs(args []string) (redacted, secrets []string) {
+	redacted = make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		if i > 0 && w.isRedactedFlag(args[i-1]) {
+			redacted[i] = redactedValue
+			secrets = append(secrets, arg)
+		} else if flag, value, ok := strings.Cut(arg, "="); ok && w.isRedactedFlag(flag) {
+			redacted[i] = flag + "=" + redactedValue
+			secrets = append(secrets, value)
+		} else if u, err := url.Parse(arg); err == nil && u.User != nil {
+			if password, ok := u.User.Password(); ok {
+				redacted[i] = strings.Replace(arg, password, redactedValue, 1)
+				secrets = append(secrets, password)
+			}
+		}
+	}
+	return
+}
+
+// commandLine formats a command for logging with secrets replaced by "***".
+func (w wrapper) commandLine(args []string) string {
+	redacted, _ := w.redactArgs(args)
+	return fmt.Sprintf("%s %s", w.executable, strings.Join(redacted, " "))
+}
+
+// redactOutput replaces any secrets passed as arguments to a command that appear in its output with "***".
+func (w wrapper) redactOutput(args []string, out []byte) []byte {
+	_, secrets := w.redactArgs(args)
+	for _, secret := range secrets {
+		if len(secret) > 0 {
+			out = bytes.ReplaceAll(out, []byte(secret), []byte(redactedValue))
+		}
+	}
+	return out
+}
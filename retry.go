@@ -0,0 +1,58 @@
+package vagrantexec
+
+import (
+	"context"
+	"time"
+)
+
+// retryPolicy describes how commands that fail for transient reasons are re-run.
+type retryPolicy struct {
+	attempts    int
+	backoff     time.Duration
+	subcommands map[string]bool
+}
+
+// WithRetry re-runs commands that fail for transient reasons, such as network errors while downloading a box, up to
+// attempts times in total. The delay between attempts starts at backoff and doubles after every failure. Only up is
+// retried unless other subcommands, e.g. "provision", are listed. Failures that are not transient, like Vagrantfile
+// syntax errors, are returned immediately and a cancelled context stops any further attempts.
+func WithRetry(attempts int, backoff time.Duration, subcommands ...string) Option {
+	if len(subcommands) == 0 {
+		subcommands = []string{"up"}
+	}
+	policy := &retryPolicy{
+		attempts:    attempts,
+		backoff:     backoff,
+		subcommands: map[string]bool{},
+	}
+	for _, sc := range subcommands {
+		policy.subcommands[sc] = true
+	}
+
+	return func(w *wrapper) {
+		w.retry = policy
+	}
+}
+
+// execRetry runs a command and retries it according to the retry policy.
+func (w wrapper) execRetry(ctx context.Context, args ...string) ([]byte, error) {
+	bs, err := w.execOnce(ctx, args...)
+	if w.retry == nil || !w.retry.subcommands[args[0]] {
+		return bs, err
+	}
+
+	fullCmd := w.commandLine(args)
+	for attempt := 1; attempt < w.retry.attempts && IsTransient(err); attempt++ {
+		delay := w.retry.backoff << uint(attempt-1)
+		w.logger.Warnf("Command [%s] failed on attempt %d of %d, retrying in %s: %s",
+			fullCmd, attempt, w.retry.attempts, delay, w.redactOutput(args, []byte(err.Error())))
+
+		select {
+		case <-ctx.Done():
+			return bs, err
+		case <-time.After(delay):
+		}
+		bs, err = w.execOnce(ctx, args...)
+	}
+	return bs, err
+}
@@ -0,0 +1,114 @@
+package vagrantexec
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetry(t *testing.T) {
+	transientErr := command.NewExitError("vagrant", 1, "An error occurred while downloading the remote file.")
+	syntaxErr := command.NewExitError("vagrant", 1, "There is a syntax error in the following Vagrantfile.")
+
+	newRetryWrapper := func(runner command.Runner, opts ...Option) wrapper {
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+
+		w := wrapper{
+			executable: binary,
+			logger:     logger,
+			runner:     runner,
+		}
+		for _, opt := range opts {
+			opt(&w)
+		}
+		return w
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		runner := new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "up")
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "up")
+		runner.AddResponse(command.Response{}, "vagrant", "up")
+
+		w := newRetryWrapper(runner, WithRetry(3, time.Millisecond))
+		require.NoError(t, w.Up())
+		assert.Len(t, runner.Calls(), 3)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		runner := new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "up")
+
+		w := newRetryWrapper(runner, WithRetry(3, time.Millisecond))
+		assert.True(t, IsTransient(w.Up()))
+		assert.Len(t, runner.Calls(), 3)
+	})
+
+	t.Run("redacts_warning", func(t *testing.T) {
+		args := []string{"box", "add", "--token", "s3cret", "ubuntu/focal64"}
+		runner := new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1,
+			"An error occurred while downloading the remote file with token s3cret.")}, "vagrant", args...)
+		logger := &recordingLogger{}
+
+		w := newRetryWrapper(runner, WithRetry(2, time.Millisecond, "box"), WithLogger(logger))
+		_, err := w.Run(args...)
+		require.Error(t, err)
+
+		var warnings []string
+		for _, line := range logger.lines {
+			if strings.HasPrefix(line, "WARN") {
+				warnings = append(warnings, line)
+			}
+		}
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "with token ***.")
+		assert.NotContains(t, warnings[0], "s3cret")
+	})
+
+	t.Run("not_transient", func(t *testing.T) {
+		runner := new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: syntaxErr}, "vagrant", "up")
+
+		w := newRetryWrapper(runner, WithRetry(3, time.Millisecond))
+		assert.Error(t, w.Up())
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("other_subcommands", func(t *testing.T) {
+		runner := new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "provision")
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "halt")
+
+		w := newRetryWrapper(runner, WithRetry(2, time.Millisecond))
+		assert.Error(t, w.Provision(ProvisionOptions{}))
+		assert.Len(t, runner.Calls(), 1)
+
+		runner = new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "provision")
+
+		w = newRetryWrapper(runner, WithRetry(2, time.Millisecond, "up", "provision"))
+		assert.Error(t, w.Provision(ProvisionOptions{}))
+		assert.Len(t, runner.Calls(), 2)
+	})
+
+	t.Run("context_done", func(t *testing.T) {
+		runner := new(command.MockRunner)
+		runner.AddResponse(command.Response{Err: transientErr}, "vagrant", "up")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		w := newRetryWrapper(runner, WithRetry(5, time.Hour))
+		assert.True(t, IsTransient(w.UpContext(ctx)))
+		assert.Len(t, runner.Calls(), 1)
+	})
+}
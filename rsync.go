@@ -0,0 +1,32 @@
+package vagrantexec
+
+import "context"
+
+// RSync syncs rsync synced folders from the host to the guest machines once. All machines are targeted when no
+// machine names are given.
+func (w wrapper) RSync(machines ...string) error {
+	return w.RSyncContext(context.Background(), machines...)
+}
+
+// RSyncContext is like RSync but includes a context.
+func (w wrapper) RSyncContext(ctx context.Context, machines ...string) error {
+	if err := validateMachineNames(machines); err != nil {
+		return err
+	}
+
+	w.logger.Infof("Syncing vagrant synced folders")
+	return w.execLogOutput(ctx, append([]string{"rsync"}, machines...)...)
+}
+
+// RSyncAuto watches rsync synced folders and syncs changes to the guest machines until the context is cancelled, at
+// which point the vagrant process and the rsync processes it started are killed and nil is returned. Output is logged
+// line by line as it is produced, or written to the writers configured with WithOutput. All machines are targeted
+// when no machine names are given.
+func (w wrapper) RSyncAuto(ctx context.Context, machines ...string) error {
+	if err := validateMachineNames(machines); err != nil {
+		return err
+	}
+
+	w.logger.Infof("Watching vagrant synced folders")
+	return w.execUntilCancelled(ctx, append([]string{"rsync-auto"}, machines...)...)
+}
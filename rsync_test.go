@@ -0,0 +1,85 @@
+package vagrantexec
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRSync(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"rsync"})([]byte("==> default: Rsyncing folder"), nil)
+		assert.NoError(t, w.RSync())
+	})
+
+	t.Run("machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"rsync", "web"})(nil, nil)
+		assert.NoError(t, w.RSync("web"))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"rsync"})(nil, nil)
+		assert.Error(t, w.RSync("web db"))
+	})
+}
+
+func TestRSyncAuto(t *testing.T) {
+	newRSyncAuto := func(resp command.Response) (wrapper, *recordingLogger) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(resp, "vagrant", "rsync-auto")
+		logger := &recordingLogger{}
+		return wrapper{executable: "vagrant", runner: runner, logger: logger}, logger
+	}
+
+	t.Run("logs_lines", func(t *testing.T) {
+		w, logger := newRSyncAuto(command.Response{
+			Output: []byte("==> default: Doing an initial rsync...\n==> default: Watching: /src\npartial"),
+		})
+
+		require.NoError(t, w.RSyncAuto(context.Background()))
+		assert.Contains(t, logger.lines, "INFO ==> default: Doing an initial rsync...")
+		assert.Contains(t, logger.lines, "INFO ==> default: Watching: /src")
+		assert.Contains(t, logger.lines, "INFO partial")
+	})
+
+	t.Run("output_writer", func(t *testing.T) {
+		w, _ := newRSyncAuto(command.Response{Output: []byte("==> default: Watching: /src\n")})
+		var stdout bytes.Buffer
+		w.stdout = &stdout
+
+		require.NoError(t, w.RSyncAuto(context.Background()))
+		assert.Equal(t, "==> default: Watching: /src\n", stdout.String())
+	})
+
+	t.Run("output_not_captured", func(t *testing.T) {
+		w, logger := newRSyncAuto(command.Response{Output: []byte("==> default: Watching: /src\n")})
+
+		require.NoError(t, w.RSyncAuto(context.Background()))
+		assert.Contains(t, logger.lines, "DEBUG Command output [vagrant rsync-auto]: ")
+	})
+
+	t.Run("combined_output", func(t *testing.T) {
+		w, logger := newRSyncAuto(command.Response{Output: []byte("==> default: Watching: /src\n")})
+		w.combined = true
+
+		require.NoError(t, w.RSyncAuto(context.Background()))
+		assert.Contains(t, logger.lines, "INFO ==> default: Watching: /src")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		w, _ := newRSyncAuto(command.Response{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.NoError(t, w.RSyncAuto(ctx))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w, _ := newRSyncAuto(command.Response{Err: command.NewExitError("vagrant", 1, "No synced folders are configured")})
+		assert.Error(t, w.RSyncAuto(context.Background()))
+	})
+}
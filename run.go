@@ -0,0 +1,22 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+)
+
+// Run executes an arbitrary vagrant subcommand, such as one added by a plugin, and returns its standard output. The
+// command runs with the same working directory, environment, logging, retry and timeout configuration as every other
+// method. args must not include the vagrant executable itself.
+func (w wrapper) Run(args ...string) ([]byte, error) {
+	return w.RunContext(context.Background(), args...)
+}
+
+// RunContext is like Run but includes a context.
+func (w wrapper) RunContext(ctx context.Context, args ...string) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errors.New("a vagrant subcommand is required")
+	}
+
+	return w.exec(ctx, args...)
+}
@@ -0,0 +1,43 @@
+package vagrantexec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	mockRun := mockedWrapperFn([]string{"vbguest", "--status", "web"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockRun([]byte("[web] GuestAdditions 6.1.16 running --- OK."), nil)
+
+		out, err := w.Run("vbguest", "--status", "web")
+		require.NoError(t, err)
+		assert.Equal(t, "[web] GuestAdditions 6.1.16 running --- OK.", string(out))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockRun(nil, errors.New("runner error"))
+
+		_, err := w.Run("vbguest", "--status", "web")
+		assert.Error(t, err)
+	})
+
+	t.Run("no_args", func(t *testing.T) {
+		w := mockRun(nil, nil)
+
+		_, err := w.Run()
+		assert.Error(t, err)
+	})
+
+	t.Run("working_dir", func(t *testing.T) {
+		w := mockRun(nil, nil)
+		w.dir = "testdata/missing"
+
+		_, err := w.Run("vbguest", "--status", "web")
+		assert.True(t, IsVagrantfileNotFound(err))
+	})
+}
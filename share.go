@@ -0,0 +1,170 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// shareNamePatterns match the lines vagrant share prints once a share is available. The classic share service prints
+// the share name, the ngrok based driver only prints the public URLs.
+var shareNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)your vagrant share is running!\s*name:\s*(\S+)`),
+	regexp.MustCompile(`(?i)\bhttps? url:\s*(\S+://\S+)`),
+}
+
+// ShareOptions configures the behavior of Vagrant.ShareStart.
+type ShareOptions struct {
+	// HTTPPort is the local port to share over HTTP. Vagrant detects it from the forwarded ports when zero.
+	HTTPPort int
+	// HTTPSPort is the local port to share over HTTPS.
+	HTTPSPort int
+	// SSH allows remote SSH access to the machine through the share.
+	SSH bool
+	// Machine is the machine to share. It can be empty if you only have one VM defined.
+	Machine string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// shareRegistry tracks the vagrant share processes started by a wrapper so that they can be stopped by name.
+type shareRegistry struct {
+	mu     sync.Mutex
+	shares map[string]*runningShare
+}
+
+// runningShare is a vagrant share process running in the background.
+type runningShare struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// ShareStart runs vagrant share in the background and returns the name of the share once vagrant reports it. For
+// shares without a name, such as those created by the ngrok driver, the public URL is returned instead. The share
+// runs until ShareStop is called with the returned name or the context is cancelled. When a share with the same name
+// is already running, the new share is stopped and an error is returned.
+func (w wrapper) ShareStart(ctx context.Context, opts ShareOptions) (shareName string, err error) {
+	if w.shares == nil {
+		return "", errors.New("share requires a wrapper created with New")
+	}
+	cmdArgs := []string{"share", "--no-color"}
+
+	if opts.HTTPPort > 0 {
+		cmdArgs = append(cmdArgs, "--http", strconv.Itoa(opts.HTTPPort))
+	}
+	if opts.HTTPSPort > 0 {
+		cmdArgs = append(cmdArgs, "--https", strconv.Itoa(opts.HTTPSPort))
+	}
+	if opts.SSH {
+		cmdArgs = append(cmdArgs, "--ssh")
+	}
+	cmdArgs, err = appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return "", err
+	}
+	if len(opts.Machine) > 0 {
+		if err := validateMachineNames([]string{opts.Machine}); err != nil {
+			return "", err
+		}
+		cmdArgs = append(cmdArgs, opts.Machine)
+	}
+
+	found := make(chan string, 1)
+	lw := &lineWriter{onLine: func(line string) {
+		w.logger.Infof("%s", line)
+		if name := parseShareName(line); len(name) > 0 {
+			select {
+			case found <- name:
+			default:
+			}
+		}
+	}}
+	if w.stdout != nil {
+		w.stdout = io.MultiWriter(w.stdout, lw)
+	} else {
+		w.stdout = lw
+	}
+	w.discardOutput = true
+
+	ctx, done := w.procs.track(ctx)
+	shareCtx, cancel := context.WithCancel(ctx)
+	lw.done = shareCtx.Done()
+	share := &runningShare{cancel: cancel, done: make(chan struct{})}
+	exited := make(chan error, 1)
+
+	w.logger.Infof("Starting vagrant share")
+	go func() {
+		defer done()
+		defer close(share.done)
+		_, err := w.exec(shareCtx, cmdArgs...)
+		lw.Flush()
+		exited <- err
+	}()
+
+	select {
+	case shareName = <-found:
+	case err = <-exited:
+		select {
+		case shareName = <-found:
+		default:
+			cancel()
+			if err == nil && !w.dryRun {
+				err = errors.New("vagrant share exited without reporting a share name")
+			}
+			return "", err
+		}
+	case <-ctx.Done():
+		cancel()
+		<-share.done
+		return "", fmt.Errorf("vagrant share interrupted: %w", ctx.Err())
+	}
+
+	w.shares.mu.Lock()
+	defer w.shares.mu.Unlock()
+	if w.shares.shares == nil {
+		w.shares.shares = map[string]*runningShare{}
+	}
+	if _, ok := w.shares.shares[shareName]; ok {
+		cancel()
+		<-share.done
+		return "", fmt.Errorf("vagrant share %q is already running", shareName)
+	}
+	w.shares.shares[shareName] = share
+
+	w.logger.Infof("Started vagrant share: %s", shareName)
+	return shareName, nil
+}
+
+// ShareStop stops a share started by ShareStart and waits for the vagrant share process to exit.
+func (w wrapper) ShareStop(shareName string) error {
+	if w.shares == nil {
+		return fmt.Errorf("no vagrant share named %q", shareName)
+	}
+
+	w.shares.mu.Lock()
+	share, ok := w.shares.shares[shareName]
+	delete(w.shares.shares, shareName)
+	w.shares.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no vagrant share named %q", shareName)
+	}
+
+	w.logger.Infof("Stopping vagrant share: %s", shareName)
+	share.cancel()
+	<-share.done
+	return nil
+}
+
+// parseShareName extracts the share name or URL from a line of vagrant share output.
+func parseShareName(line string) string {
+	for _, pattern := range shareNamePatterns {
+		if m := pattern.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package vagrantexec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// longRunningRunner writes its output and then blocks like vagrant share until the context is done.
+type longRunningRunner struct {
+	command.MockRunner
+	output  []byte
+	stopped chan struct{}
+}
+
+func (r *longRunningRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	r.MockRunner.ExecuteStream(ctx, ioutil.Discard, nil, cmd, args...)
+	stdout.Write(r.output)
+	<-ctx.Done()
+	close(r.stopped)
+	return fmt.Errorf("%s interrupted: %w", cmd, ctx.Err())
+}
+
+func TestShare(t *testing.T) {
+	newShareWrapper := func(fixture string, opts ...Option) (Vagrant, *longRunningRunner) {
+		output, err := ioutil.ReadFile(fixture)
+		require.NoError(t, err)
+
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+		runner := &longRunningRunner{output: output, stopped: make(chan struct{})}
+		opts = append([]Option{WithRunner(runner), WithLogger(logger), WithOutput(ioutil.Discard, nil)}, opts...)
+		w := New(".", false, opts...).(wrapper)
+		w.dir = ""
+		return w, runner
+	}
+
+	t.Run("ngrok_url", func(t *testing.T) {
+		w, runner := newShareWrapper("testdata/share")
+
+		name, err := w.ShareStart(context.Background(), ShareOptions{HTTPPort: 8080, SSH: true, Machine: "web"})
+		require.NoError(t, err)
+		assert.Equal(t, "http://b1fb1f3f.ngrok.io", name)
+		assert.Equal(t, "vagrant share --no-color --http 8080 --ssh web", runner.Calls()[0].String())
+
+		require.NoError(t, w.ShareStop(name))
+		<-runner.stopped
+	})
+
+	t.Run("classic_name", func(t *testing.T) {
+		w, runner := newShareWrapper("testdata/share-classic")
+
+		name, err := w.ShareStart(context.Background(), ShareOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "bazaar-wolf-4343", name)
+
+		require.NoError(t, w.ShareStop(name))
+		<-runner.stopped
+		assert.Error(t, w.ShareStop(name))
+	})
+
+	t.Run("duplicate_name", func(t *testing.T) {
+		w, runner := newShareWrapper("testdata/share-classic")
+		second := &longRunningRunner{output: runner.output, stopped: make(chan struct{})}
+		w2 := w.(wrapper)
+		w2.runner = second
+
+		name, err := w.ShareStart(context.Background(), ShareOptions{})
+		require.NoError(t, err)
+
+		_, err = w2.ShareStart(context.Background(), ShareOptions{})
+		require.Error(t, err)
+		assert.Equal(t, `vagrant share "bazaar-wolf-4343" is already running`, err.Error())
+		<-second.stopped
+
+		require.NoError(t, w.ShareStop(name))
+		<-runner.stopped
+	})
+
+	t.Run("combined_output", func(t *testing.T) {
+		w, runner := newShareWrapper("testdata/share", WithCombinedOutput(true))
+
+		name, err := w.ShareStart(context.Background(), ShareOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "http://b1fb1f3f.ngrok.io", name)
+
+		require.NoError(t, w.ShareStop(name))
+		<-runner.stopped
+	})
+
+	t.Run("context_stops_share", func(t *testing.T) {
+		w, runner := newShareWrapper("testdata/share")
+		ctx, cancel := context.WithCancel(context.Background())
+
+		_, err := w.ShareStart(ctx, ShareOptions{})
+		require.NoError(t, err)
+
+		cancel()
+		<-runner.stopped
+	})
+
+	t.Run("exits_without_name", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: command.NewExitError("vagrant", 1, "vagrant-share plugin is not installed")}, "vagrant", "share", "--no-color")
+		w := New(".", false, WithRunner(runner), WithLogger(&recordingLogger{})).(wrapper)
+		w.dir = ""
+
+		_, err := w.ShareStart(context.Background(), ShareOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown_share", func(t *testing.T) {
+		w, _ := newShareWrapper("testdata/share")
+		assert.Error(t, w.ShareStop("missing"))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w, _ := newShareWrapper("testdata/share")
+
+		_, err := w.ShareStart(context.Background(), ShareOptions{Machine: "web db"})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseShareName(t *testing.T) {
+	assert.Equal(t, "https://abc.ngrok.io", parseShareName("==> default: HTTPS URL: https://abc.ngrok.io"))
+	assert.Equal(t, "bazaar-wolf-4343", parseShareName("==> default: Your Vagrant Share is running! Name: bazaar-wolf-4343"))
+	assert.Empty(t, parseShareName("    default: Local HTTP port: 8080"))
+	assert.Empty(t, parseShareName("==> default: URL:"))
+}
@@ -0,0 +1,151 @@
+package vagrantexec
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+)
+
+const (
+	// noSnapshotsMessage is printed by vagrant instead of a list when a machine has no snapshots.
+	noSnapshotsMessage = "No snapshots have been taken yet"
+	// noPushedSnapshotMessage is printed by vagrant when popping a snapshot off an empty stack.
+	noPushedSnapshotMessage = "No pushed snapshot found"
+)
+
+// ErrNoPushedSnapshot is returned by SnapshotPop when there is no pushed snapshot to restore.
+var ErrNoPushedSnapshot = errors.New("no pushed snapshot found")
+
+// SnapshotPopOptions configures the behavior of Vagrant.SnapshotPop.
+type SnapshotPopOptions struct {
+	// NoProvision disables the provisioners that would otherwise run after the snapshot is restored.
+	NoProvision bool
+	// NoDelete keeps the snapshot after restoring it instead of removing it from the stack.
+	NoDelete bool
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// SnapshotSave takes a snapshot of the current state of the machine under the given name.
+func (w wrapper) SnapshotSave(name string) error {
+	return w.SnapshotSaveContext(context.Background(), name)
+}
+
+// SnapshotSaveContext is like SnapshotSave but includes a context.
+func (w wrapper) SnapshotSaveContext(ctx context.Context, name string) error {
+	if len(name) == 0 {
+		return errors.New("snapshot must have a name")
+	}
+
+	w.logger.Infof("Saving vagrant snapshot: %s", name)
+	return w.execLogOutput(ctx, "snapshot", "save", name)
+}
+
+// SnapshotRestore restores the machine to the named snapshot.
+func (w wrapper) SnapshotRestore(name string) error {
+	return w.SnapshotRestoreContext(context.Background(), name)
+}
+
+// SnapshotRestoreContext is like SnapshotRestore but includes a context.
+func (w wrapper) SnapshotRestoreContext(ctx context.Context, name string) error {
+	if len(name) == 0 {
+		return errors.New("snapshot must have a name")
+	}
+
+	w.logger.Infof("Restoring vagrant snapshot: %s", name)
+	return w.execLogOutput(ctx, "snapshot", "restore", name)
+}
+
+// SnapshotList returns the names of all snapshots taken of the machine. An empty slice is returned when no snapshots
+// have been taken.
+func (w wrapper) SnapshotList() ([]string, error) {
+	return w.SnapshotListContext(context.Background())
+}
+
+// SnapshotListContext is like SnapshotList but includes a context.
+func (w wrapper) SnapshotListContext(ctx context.Context) ([]string, error) {
+	out, err := w.exec(ctx, "snapshot", "list", "--no-color")
+	if err != nil {
+		return nil, err
+	}
+	return parseSnapshotList(out)
+}
+
+// SnapshotDelete deletes the named snapshot.
+func (w wrapper) SnapshotDelete(name string) error {
+	return w.SnapshotDeleteContext(context.Background(), name)
+}
+
+// SnapshotDeleteContext is like SnapshotDelete but includes a context.
+func (w wrapper) SnapshotDeleteContext(ctx context.Context, name string) error {
+	if len(name) == 0 {
+		return errors.New("snapshot must have a name")
+	}
+
+	w.logger.Infof("Deleting vagrant snapshot: %s", name)
+	return w.execLogOutput(ctx, "snapshot", "delete", name)
+}
+
+// SnapshotPush takes a snapshot and pushes it onto the snapshot stack. It is a shorthand for SnapshotSave that does not
+// require a name.
+func (w wrapper) SnapshotPush() error {
+	return w.SnapshotPushContext(context.Background())
+}
+
+// SnapshotPushContext is like SnapshotPush but includes a context.
+func (w wrapper) SnapshotPushContext(ctx context.Context) error {
+	w.logger.Infof("Pushing vagrant snapshot")
+	return w.execLogOutput(ctx, "snapshot", "push")
+}
+
+// SnapshotPop restores the snapshot on the top of the stack and removes it. ErrNoPushedSnapshot is returned when the
+// stack is empty.
+func (w wrapper) SnapshotPop(opts SnapshotPopOptions) error {
+	return w.SnapshotPopContext(context.Background(), opts)
+}
+
+// SnapshotPopContext is like SnapshotPop but includes a context.
+func (w wrapper) SnapshotPopContext(ctx context.Context, opts SnapshotPopOptions) error {
+	cmdArgs := []string{"snapshot", "pop"}
+
+	if opts.NoProvision {
+		cmdArgs = append(cmdArgs, "--no-provision")
+	}
+	if opts.NoDelete {
+		cmdArgs = append(cmdArgs, "--no-delete")
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+
+	w.logger.Infof("Popping vagrant snapshot")
+	out, err := w.exec(ctx, cmdArgs...)
+	if strings.Contains(string(out), noPushedSnapshotMessage) ||
+		(err != nil && strings.Contains(err.Error(), noPushedSnapshotMessage)) {
+		return ErrNoPushedSnapshot
+	}
+	w.logOutput(out)
+	return err
+}
+
+// parseSnapshotList extracts snapshot names from the plain-text output of snapshot list. Machine headers ("==>") and
+// indented informational lines are skipped.
+func parseSnapshotList(out []byte) ([]string, error) {
+	out = stripANSI(out)
+	names := []string{}
+	if strings.Contains(string(out), noSnapshotsMessage) {
+		return names, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(line, "==>") || strings.HasPrefix(line, " ") {
+			continue
+		}
+		names = append(names, strings.TrimSpace(line))
+	}
+	return names, scanner.Err()
+}
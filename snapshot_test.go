@@ -0,0 +1,139 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotSave(t *testing.T) {
+	mockSnapshotSave := mockedWrapperFn([]string{"snapshot", "save", "clean-install"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockSnapshotSave(nil, nil)
+		assert.NoError(t, w.SnapshotSave("clean-install"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSnapshotSave(nil, errors.New("runner error"))
+		assert.Error(t, w.SnapshotSave("clean-install"))
+	})
+
+	t.Run("no_name", func(t *testing.T) {
+		w := mockSnapshotSave(nil, nil)
+		assert.EqualError(t, w.SnapshotSave(""), "snapshot must have a name")
+	})
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	mockSnapshotRestore := mockedWrapperFn([]string{"snapshot", "restore", "clean-install"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockSnapshotRestore(nil, nil)
+		assert.NoError(t, w.SnapshotRestore("clean-install"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSnapshotRestore(nil, errors.New("runner error"))
+		assert.Error(t, w.SnapshotRestore("clean-install"))
+	})
+
+	t.Run("no_name", func(t *testing.T) {
+		w := mockSnapshotRestore(nil, nil)
+		assert.Error(t, w.SnapshotRestore(""))
+	})
+}
+
+func TestSnapshotList(t *testing.T) {
+	mockSnapshotList := mockedWrapperFn([]string{"snapshot", "list", "--no-color"})
+
+	t.Run("with_snapshots", func(t *testing.T) {
+		w := mockSnapshotList(ioutil.ReadFile("testdata/snapshot-list"))
+
+		names, err := w.SnapshotList()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"clean-install", "before-upgrade"}, names)
+	})
+
+	t.Run("no_snapshots", func(t *testing.T) {
+		w := mockSnapshotList(ioutil.ReadFile("testdata/snapshot-list-none"))
+
+		names, err := w.SnapshotList()
+		require.NoError(t, err)
+		assert.NotNil(t, names)
+		assert.Empty(t, names)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSnapshotList(nil, errors.New("runner error"))
+
+		_, err := w.SnapshotList()
+		assert.Error(t, err)
+	})
+}
+
+func TestSnapshotDelete(t *testing.T) {
+	mockSnapshotDelete := mockedWrapperFn([]string{"snapshot", "delete", "clean-install"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockSnapshotDelete(nil, nil)
+		assert.NoError(t, w.SnapshotDelete("clean-install"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSnapshotDelete(nil, errors.New("runner error"))
+		assert.Error(t, w.SnapshotDelete("clean-install"))
+	})
+
+	t.Run("no_name", func(t *testing.T) {
+		w := mockSnapshotDelete(nil, nil)
+		assert.Error(t, w.SnapshotDelete(""))
+	})
+}
+
+func TestSnapshotPush(t *testing.T) {
+	mockSnapshotPush := mockedWrapperFn([]string{"snapshot", "push"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockSnapshotPush(nil, nil)
+		assert.NoError(t, w.SnapshotPush())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSnapshotPush(nil, errors.New("runner error"))
+		assert.Error(t, w.SnapshotPush())
+	})
+}
+
+func TestSnapshotPop(t *testing.T) {
+	mockSnapshotPop := mockedWrapperFn([]string{"snapshot", "pop"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockSnapshotPop([]byte("==> default: Restoring the snapshot 'push_1563_4567'..."), nil)
+		assert.NoError(t, w.SnapshotPop(SnapshotPopOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSnapshotPop(nil, errors.New("runner error"))
+
+		err := w.SnapshotPop(SnapshotPopOptions{})
+		require.Error(t, err)
+		assert.NotEqual(t, ErrNoPushedSnapshot, err)
+	})
+
+	t.Run("with_options", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"snapshot", "pop", "--no-provision", "--no-delete"})(nil, nil)
+		assert.NoError(t, w.SnapshotPop(SnapshotPopOptions{NoProvision: true, NoDelete: true}))
+	})
+
+	t.Run("empty_stack", func(t *testing.T) {
+		w := mockSnapshotPop(nil, errors.New("vagrant exited with status 1: No pushed snapshot found!"))
+		assert.Equal(t, ErrNoPushedSnapshot, w.SnapshotPop(SnapshotPopOptions{}))
+
+		w = mockSnapshotPop([]byte("==> default: No pushed snapshot found!"), nil)
+		assert.Equal(t, ErrNoPushedSnapshot, w.SnapshotPop(SnapshotPopOptions{}))
+	})
+}
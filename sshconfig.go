@@ -0,0 +1,139 @@
+package vagrantexec
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SSHInfo contains the SSH connection details of a machine as reported by vagrant ssh-config.
+type SSHInfo struct {
+	Host         string
+	HostName     string
+	User         string
+	Port         int
+	IdentityFile string
+	// Options contains every other OpenSSH option in the config, e.g. StrictHostKeyChecking or ProxyCommand.
+	Options map[string]string
+}
+
+// SSHConfig returns the SSH connection details of a machine. You can use an empty string as the machine if you only
+// have one VM defined in your Vagrantfile.
+func (w wrapper) SSHConfig(machine string) (*SSHInfo, error) {
+	return w.SSHConfigContext(context.Background(), machine)
+}
+
+// SSHConfigContext is like SSHConfig but includes a context.
+func (w wrapper) SSHConfigContext(ctx context.Context, machine string) (*SSHInfo, error) {
+	cmdArgs := []string{"ssh-config", "--no-color"}
+	if len(machine) > 0 {
+		if err := validateMachineNames([]string{machine}); err != nil {
+			return nil, err
+		}
+		cmdArgs = append(cmdArgs, machine)
+	}
+
+	out, err := w.exec(ctx, cmdArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if w.dryRun {
+		return &SSHInfo{}, nil
+	}
+	hosts, err := parseSSHConfig(out)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(hosts) == 0:
+		return nil, errors.New("no ssh configuration returned")
+	case len(machine) == 0 && len(hosts) > 1:
+		return nil, errors.New("multiple machines defined, a machine name is required")
+	}
+	for i := range hosts {
+		if hosts[i].Host == machine {
+			return &hosts[i], nil
+		}
+	}
+	return &hosts[0], nil
+}
+
+// SSHPort returns the port on which the SSH server of a machine can be reached, which is useful for health checks.
+// The machine name may be omitted if you only have one VM defined in your Vagrantfile. An error is returned when the
+// machine is not running or not yet ready for SSH.
+func (w wrapper) SSHPort(machine ...string) (int, error) {
+	return w.SSHPortContext(context.Background(), machine...)
+}
+
+// SSHPortContext is like SSHPort but includes a context.
+func (w wrapper) SSHPortContext(ctx context.Context, machine ...string) (int, error) {
+	var name string
+	switch len(machine) {
+	case 0:
+	case 1:
+		name = machine[0]
+	default:
+		return 0, errors.New("only one machine name may be given")
+	}
+
+	info, err := w.SSHConfigContext(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("ssh is not available: %w", err)
+	}
+	if info.Port == 0 && !w.dryRun {
+		return 0, errors.New("ssh is not available: no port reported")
+	}
+	return info.Port, nil
+}
+
+// parseSSHConfig converts OpenSSH-style configuration into one SSHInfo per Host block.
+func parseSSHConfig(out []byte) (hosts []SSHInfo, err error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(stripANSI(out))))
+
+	var host *SSHInfo
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var key, value string
+		if idx := strings.IndexAny(line, " \t"); idx > 0 {
+			key, value = line[:idx], strings.TrimSpace(line[idx:])
+		} else {
+			return nil, fmt.Errorf("invalid ssh-config line: %s", line)
+		}
+
+		if key == "Host" {
+			hosts = append(hosts, SSHInfo{Host: value, Options: map[string]string{}})
+			host = &hosts[len(hosts)-1]
+			continue
+		}
+		if host == nil {
+			return nil, fmt.Errorf("ssh-config option outside of a Host block: %s", line)
+		}
+
+		switch key {
+		case "HostName":
+			host.HostName = value
+		case "User":
+			host.User = value
+		case "Port":
+			if host.Port, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid ssh-config port %q: %w", value, err)
+			}
+		case "IdentityFile":
+			if len(host.IdentityFile) == 0 {
+				host.IdentityFile = strings.Trim(value, `"`)
+			}
+		default:
+			host.Options[key] = value
+		}
+	}
+	err = scanner.Err()
+	return
+}
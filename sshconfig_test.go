@@ -0,0 +1,147 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHConfig(t *testing.T) {
+	mockSSHConfig := mockedWrapperFn([]string{"ssh-config", "--no-color"})
+
+	t.Run("single_machine", func(t *testing.T) {
+		w := mockSSHConfig(ioutil.ReadFile("testdata/ssh-config"))
+
+		info, err := w.SSHConfig("")
+		require.NoError(t, err)
+
+		expected := &SSHInfo{
+			Host:         "default",
+			HostName:     "127.0.0.1",
+			User:         "vagrant",
+			Port:         2222,
+			IdentityFile: "/home/user/project/.vagrant/machines/default/virtualbox/private_key",
+			Options: map[string]string{
+				"UserKnownHostsFile":     "/dev/null",
+				"StrictHostKeyChecking":  "no",
+				"PasswordAuthentication": "no",
+				"IdentitiesOnly":         "yes",
+				"LogLevel":               "FATAL",
+			},
+		}
+		assert.Equal(t, expected, info)
+	})
+
+	t.Run("named_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh-config", "--no-color", "db"})(ioutil.ReadFile("testdata/ssh-config-multiple"))
+
+		info, err := w.SSHConfig("db")
+		require.NoError(t, err)
+		assert.Equal(t, "db", info.Host)
+		assert.Equal(t, "192.168.121.45", info.HostName)
+	})
+
+	t.Run("multiple_machines", func(t *testing.T) {
+		w := mockSSHConfig(ioutil.ReadFile("testdata/ssh-config-multiple"))
+
+		_, err := w.SSHConfig("")
+		assert.EqualError(t, err, "multiple machines defined, a machine name is required")
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockSSHConfig(nil, nil)
+
+		_, err := w.SSHConfig("web && true")
+		assert.Error(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSSHConfig(nil, errors.New("runner error"))
+
+		_, err := w.SSHConfig("")
+		assert.Error(t, err)
+	})
+}
+
+func TestSSHPort(t *testing.T) {
+	t.Run("single_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh-config", "--no-color"})(ioutil.ReadFile("testdata/ssh-config"))
+
+		port, err := w.SSHPort()
+		require.NoError(t, err)
+		assert.Equal(t, 2222, port)
+	})
+
+	t.Run("named_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh-config", "--no-color", "db"})(ioutil.ReadFile("testdata/ssh-config-multiple"))
+
+		info, err := w.SSHConfig("db")
+		require.NoError(t, err)
+
+		port, err := w.SSHPort("db")
+		require.NoError(t, err)
+		assert.Equal(t, info.Port, port)
+	})
+
+	t.Run("not_running", func(t *testing.T) {
+		exitErr := command.NewExitError("vagrant", 1, "The provider for this Vagrant-managed machine is reporting that it\nis not yet ready for SSH.")
+		w := mockedWrapperFn([]string{"ssh-config", "--no-color"})(nil, exitErr)
+
+		_, err := w.SSHPort()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ssh is not available")
+		assert.True(t, errors.Is(err, exitErr))
+	})
+
+	t.Run("no_port", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh-config", "--no-color"})([]byte("Host default\n  HostName 127.0.0.1\n"), nil)
+
+		_, err := w.SSHPort()
+		assert.EqualError(t, err, "ssh is not available: no port reported")
+	})
+
+	t.Run("multiple_names", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh-config", "--no-color"})(nil, nil)
+
+		_, err := w.SSHPort("web", "db")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSSHConfig(t *testing.T) {
+	t.Run("multiple_hosts", func(t *testing.T) {
+		out, err := ioutil.ReadFile("testdata/ssh-config-multiple")
+		require.NoError(t, err)
+
+		hosts, err := parseSSHConfig(out)
+		require.NoError(t, err)
+		require.Len(t, hosts, 2)
+
+		assert.Equal(t, "web", hosts[0].Host)
+		assert.Equal(t, 2222, hosts[0].Port)
+		assert.Equal(t, "db", hosts[1].Host)
+		assert.Equal(t, "192.168.121.45", hosts[1].HostName)
+		assert.Equal(t, 22, hosts[1].Port)
+		assert.Equal(t, "ssh -q -W %h:%p libvirt-host", hosts[1].Options["ProxyCommand"])
+	})
+
+	t.Run("bad_port", func(t *testing.T) {
+		_, err := parseSSHConfig([]byte("Host default\n  Port abc\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("option_without_host", func(t *testing.T) {
+		_, err := parseSSHConfig([]byte("  User vagrant\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		hosts, err := parseSSHConfig(nil)
+		require.NoError(t, err)
+		assert.Empty(t, hosts)
+	})
+}
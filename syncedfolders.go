@@ -0,0 +1,116 @@
+package vagrantexec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncedFolder is a folder shared between the host and a machine.
+type SyncedFolder struct {
+	HostPath  string
+	GuestPath string
+	// Type is the synced folder implementation, e.g. "virtualbox", "rsync" or "nfs".
+	Type string
+}
+
+// syncedFolderEntry is a single folder in the synced_folders file vagrant writes for every created machine.
+type syncedFolderEntry struct {
+	GuestPath string `json:"guestpath"`
+	HostPath  string `json:"hostpath"`
+	Disabled  bool   `json:"disabled"`
+}
+
+// SyncedFolders returns the enabled synced folders of a machine, sorted by guest path. They are read from the data
+// vagrant keeps in the .vagrant directory of the environment, so the machine must have been brought up at least once.
+// The machine name may be omitted if only one machine has been created.
+func (w wrapper) SyncedFolders(machine ...string) ([]SyncedFolder, error) {
+	if len(machine) > 1 {
+		return nil, errors.New("only one machine name may be given")
+	}
+	if err := validateMachineNames(machine); err != nil {
+		return nil, err
+	}
+
+	machinesDir, err := w.machinesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	if len(machine) == 1 {
+		name = machine[0]
+		if strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid machine name %q", name)
+		}
+	} else {
+		names, err := filepath.Glob(filepath.Join(machinesDir, "*"))
+		if err != nil {
+			return nil, err
+		}
+		switch len(names) {
+		case 0:
+			return nil, errors.New("no machines have been created")
+		case 1:
+			name = filepath.Base(names[0])
+		default:
+			return nil, errors.New("multiple machines defined, a machine name is required")
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(machinesDir, name, "*", "synced_folders"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no synced folders recorded for machine %s, it may not have been created", name)
+	}
+
+	bs, err := os.ReadFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	var byType map[string]map[string]syncedFolderEntry
+	if err := json.Unmarshal(bs, &byType); err != nil {
+		return nil, fmt.Errorf("invalid synced folders for machine %s: %w", name, err)
+	}
+
+	var folders []SyncedFolder
+	for folderType, entries := range byType {
+		for _, entry := range entries {
+			if entry.Disabled {
+				continue
+			}
+			folders = append(folders, SyncedFolder{HostPath: entry.HostPath, GuestPath: entry.GuestPath, Type: folderType})
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].GuestPath < folders[j].GuestPath
+	})
+	return folders, nil
+}
+
+// machinesDir returns the directory in which vagrant stores the data of the environment's machines, honoring
+// VAGRANT_DOTFILE_PATH.
+func (w wrapper) machinesDir() (string, error) {
+	root, err := w.vagrantfileDir()
+	if err != nil {
+		return "", err
+	}
+
+	dotfile, ok := w.env["VAGRANT_DOTFILE_PATH"]
+	if !ok && !w.envOverride {
+		dotfile = os.Getenv("VAGRANT_DOTFILE_PATH")
+	}
+	if len(dotfile) == 0 {
+		dotfile = ".vagrant"
+	}
+	if !filepath.IsAbs(dotfile) {
+		dotfile = filepath.Join(root, dotfile)
+	}
+	return filepath.Join(dotfile, "machines"), nil
+}
@@ -0,0 +1,60 @@
+package vagrantexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncedFolders(t *testing.T) {
+	newWrapper := func(dir string) wrapper {
+		return wrapper{executable: binary, dir: dir, logger: &recordingLogger{}}
+	}
+
+	t.Run("named_machine", func(t *testing.T) {
+		folders, err := newWrapper("testdata/synced").SyncedFolders("web")
+		require.NoError(t, err)
+
+		expected := []SyncedFolder{
+			{HostPath: "/home/user/project/app", GuestPath: "/srv/app", Type: "rsync"},
+			{HostPath: "/home/user/project", GuestPath: "/vagrant", Type: "virtualbox"},
+		}
+		assert.Equal(t, expected, folders)
+	})
+
+	t.Run("other_provider", func(t *testing.T) {
+		folders, err := newWrapper("testdata/synced").SyncedFolders("db")
+		require.NoError(t, err)
+		assert.Equal(t, []SyncedFolder{{HostPath: "/home/user/project", GuestPath: "/vagrant", Type: "nfs"}}, folders)
+	})
+
+	t.Run("not_created", func(t *testing.T) {
+		_, err := newWrapper("testdata/synced").SyncedFolders("worker")
+		assert.EqualError(t, err, "no synced folders recorded for machine worker, it may not have been created")
+	})
+
+	t.Run("name_required", func(t *testing.T) {
+		_, err := newWrapper("testdata/synced").SyncedFolders()
+		assert.EqualError(t, err, "multiple machines defined, a machine name is required")
+	})
+
+	t.Run("dotfile_path", func(t *testing.T) {
+		w := newWrapper("testdata/env")
+		w.env = map[string]string{"VAGRANT_DOTFILE_PATH": "../synced/.vagrant"}
+
+		folders, err := w.SyncedFolders("db")
+		require.NoError(t, err)
+		assert.Len(t, folders, 1)
+	})
+
+	t.Run("no_machines", func(t *testing.T) {
+		_, err := newWrapper("testdata/env").SyncedFolders()
+		assert.EqualError(t, err, "no machines have been created")
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		_, err := newWrapper("testdata/synced").SyncedFolders("../machines/web")
+		assert.EqualError(t, err, `invalid machine name "../machines/web"`)
+	})
+}
@@ -0,0 +1,61 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// TeardownOptions configures the behavior of Vagrant.Teardown.
+type TeardownOptions struct {
+	// Halt shuts the machines down before destroying them.
+	Halt bool
+	// Graceful attempts a clean shutdown of the guests during destroy. See DestroyOptions.Graceful.
+	Graceful bool
+	// Parallel enables or disables destroying machines in parallel. See DestroyOptions.Parallel.
+	Parallel *bool
+	// Machines limits the teardown to the named machines. All machines are torn down when empty.
+	Machines []string
+	// ExtraArgs are passed verbatim to both the halt and the destroy command. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// Teardown optionally halts the machines and then destroys them. A failed halt does not prevent the destroy, which
+// forces the machines down, so that no machine is left half torn down; the errors of both phases are returned
+// together. A cancelled context stops the teardown before the next phase.
+func (w wrapper) Teardown(opts TeardownOptions) error {
+	return w.TeardownContext(context.Background(), opts)
+}
+
+// TeardownContext is like Teardown but includes a context.
+func (w wrapper) TeardownContext(ctx context.Context, opts TeardownOptions) error {
+	if err := validateMachineNames(opts.Machines); err != nil {
+		return err
+	}
+	if _, err := appendExtraArgs(nil, opts.ExtraArgs); err != nil {
+		return err
+	}
+
+	var errs []error
+	if opts.Halt {
+		w.logger.Infof("Teardown: halting vagrant machines")
+		if err := w.HaltWithOptionsContext(ctx, HaltOptions{ExtraArgs: opts.ExtraArgs, Machines: opts.Machines}); err != nil {
+			errs = append(errs, fmt.Errorf("halt: %w", err))
+		}
+		if ctx.Err() != nil {
+			return errors.Join(errs...)
+		}
+	}
+
+	w.logger.Infof("Teardown: destroying vagrant machines")
+	destroyOpts := DestroyOptions{
+		Graceful:  opts.Graceful,
+		Parallel:  opts.Parallel,
+		ExtraArgs: opts.ExtraArgs,
+		Machines:  opts.Machines,
+	}
+	if err := w.DestroyWithOptionsContext(ctx, destroyOpts); err != nil {
+		errs = append(errs, fmt.Errorf("destroy: %w", err))
+	}
+	return errors.Join(errs...)
+}
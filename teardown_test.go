@@ -0,0 +1,97 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeardown(t *testing.T) {
+	t.Run("destroy_only", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		require.NoError(t, w.Teardown(TeardownOptions{}))
+		require.Len(t, runner.Calls(), 1)
+		assert.Equal(t, "vagrant destroy --force", runner.Calls()[0].String())
+	})
+
+	t.Run("halt_then_destroy", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		logger := &recordingLogger{}
+		w := wrapper{executable: binary, runner: runner, logger: logger}
+
+		require.NoError(t, w.Teardown(TeardownOptions{Halt: true, Graceful: true, Machines: []string{"db"}}))
+
+		calls := runner.Calls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "vagrant halt db", calls[0].String())
+		assert.Equal(t, "vagrant destroy --force --graceful db", calls[1].String())
+		assert.Contains(t, logger.lines, "INFO Teardown: halting vagrant machines")
+		assert.Contains(t, logger.lines, "INFO Teardown: destroying vagrant machines")
+	})
+
+	t.Run("extra_args", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		require.NoError(t, w.Teardown(TeardownOptions{Halt: true, ExtraArgs: []string{"--timestamp"}}))
+
+		calls := runner.Calls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "vagrant halt --timestamp", calls[0].String())
+		assert.Equal(t, "vagrant destroy --force --timestamp", calls[1].String())
+	})
+
+	t.Run("invalid_extra_args", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		assert.Error(t, w.Teardown(TeardownOptions{Halt: true, ExtraArgs: []string{"db"}}))
+		assert.Empty(t, runner.Calls())
+	})
+
+	t.Run("halt_error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("halt failed")}, "vagrant", "halt")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.Teardown(TeardownOptions{Halt: true})
+		assert.EqualError(t, err, "halt: halt failed")
+		assert.Len(t, runner.Calls(), 2)
+	})
+
+	t.Run("both_errors", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("halt failed")}, "vagrant", "halt")
+		runner.AddResponse(command.Response{Err: errors.New("destroy failed")}, "vagrant", "destroy", "--force")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.Teardown(TeardownOptions{Halt: true})
+		assert.EqualError(t, err, "halt: halt failed\ndestroy: destroy failed")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.TeardownContext(ctx, TeardownOptions{Halt: true})
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		assert.Error(t, w.Teardown(TeardownOptions{Machines: []string{"-f"}}))
+		assert.Empty(t, runner.Calls())
+	})
+}
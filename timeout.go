@@ -0,0 +1,60 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned when a command is killed because it ran longer than the timeout configured for its
+// subcommand with WithTimeouts.
+type TimeoutError struct {
+	// Subcommand is the vagrant subcommand that timed out, e.g. "up".
+	Subcommand string
+	// Timeout is the configured timeout.
+	Timeout time.Duration
+	// Err is the underlying error, which wraps context.DeadlineExceeded.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("vagrant %s timed out after %s", e.Subcommand, e.Timeout)
+}
+
+// Unwrap returns the underlying error.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WithTimeouts limits how long commands may run, keyed by vagrant subcommand, e.g. {"up": 30 * time.Minute, "status":
+// 10 * time.Second}. Commands without an entry run without a timeout. The timeout covers all retry attempts and
+// applies in addition to any deadline on the context passed to a Context method. A command that times out is killed
+// and a *TimeoutError is returned. Repeated calls add to the previously configured timeouts.
+func WithTimeouts(timeouts map[string]time.Duration) Option {
+	return func(w *wrapper) {
+		if w.timeouts == nil {
+			w.timeouts = map[string]time.Duration{}
+		}
+		for subcommand, timeout := range timeouts {
+			w.timeouts[subcommand] = timeout
+		}
+	}
+}
+
+// execTimeout runs a command with the timeout configured for its subcommand, if any.
+func (w wrapper) execTimeout(ctx context.Context, args ...string) ([]byte, error) {
+	timeout, ok := w.timeouts[args[0]]
+	if !ok || timeout <= 0 {
+		return w.execRetry(ctx, args...)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	bs, err := w.execRetry(timeoutCtx, args...)
+	if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		err = &TimeoutError{Subcommand: args[0], Timeout: timeout, Err: err}
+	}
+	return bs, err
+}
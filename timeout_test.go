@@ -0,0 +1,89 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingRunner blocks every command until the context is done, like a provider that stopped responding.
+type hangingRunner struct {
+	command.MockRunner
+}
+
+func (r *hangingRunner) ExecuteContext(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	r.MockRunner.ExecuteContext(context.Background(), cmd, args...)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (r *hangingRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, args ...string) error {
+	_, err := r.ExecuteContext(ctx, cmd, args...)
+	return err
+}
+
+func TestWithTimeouts(t *testing.T) {
+	newTimeoutWrapper := func(runner command.Runner) wrapper {
+		w := New(".", false, WithRunner(runner), WithLogger(&recordingLogger{}), WithTimeouts(map[string]time.Duration{
+			"up":     10 * time.Millisecond,
+			"status": time.Minute,
+		})).(wrapper)
+		w.dir = ""
+		return w
+	}
+
+	t.Run("timed_out", func(t *testing.T) {
+		w := newTimeoutWrapper(&hangingRunner{})
+
+		err := w.Up()
+
+		var te *TimeoutError
+		require.True(t, errors.As(err, &te))
+		assert.Equal(t, "up", te.Subcommand)
+		assert.Equal(t, 10*time.Millisecond, te.Timeout)
+		assert.Equal(t, "vagrant up timed out after 10ms", te.Error())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("within_timeout", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: []byte("1562176079,default,state,running\n")}, "vagrant", "status", "--machine-readable", "--no-color")
+		w := newTimeoutWrapper(runner)
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+		assert.Len(t, statuses, 1)
+	})
+
+	t.Run("no_timeout", func(t *testing.T) {
+		w := newTimeoutWrapper(&command.MockRunner{})
+		assert.NoError(t, w.Halt())
+	})
+
+	t.Run("parent_context_cancelled", func(t *testing.T) {
+		w := newTimeoutWrapper(&hangingRunner{})
+		w.timeouts["up"] = time.Minute
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := w.UpContext(ctx)
+
+		var te *TimeoutError
+		require.Error(t, err)
+		assert.False(t, errors.As(err, &te))
+	})
+
+	t.Run("merges", func(t *testing.T) {
+		w := New(".", false,
+			WithTimeouts(map[string]time.Duration{"up": time.Minute}),
+			WithTimeouts(map[string]time.Duration{"halt": time.Second}),
+		).(wrapper)
+		assert.Equal(t, map[string]time.Duration{"up": time.Minute, "halt": time.Second}, w.timeouts)
+	})
+}
@@ -0,0 +1,67 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UploadOptions configures the behavior of Vagrant.Upload.
+type UploadOptions struct {
+	// Compress archives the source before uploading it and extracts it on the guest, which is faster for directories
+	// with many files.
+	Compress bool
+	// Temporary uploads the source to a temporary location on the guest instead of the destination path.
+	Temporary bool
+	// Machine is the machine to upload to. It can be empty if you only have one VM defined.
+	Machine string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// Upload copies a file or directory from the host to a guest machine over its communicator (SSH or WinRM). Relative
+// source paths are resolved against the Vagrantfile directory and must exist. Requires Vagrant 2.2.0 or later.
+func (w wrapper) Upload(source, dest string, opts UploadOptions) error {
+	return w.UploadContext(context.Background(), source, dest, opts)
+}
+
+// UploadContext is like Upload but includes a context.
+func (w wrapper) UploadContext(ctx context.Context, source, dest string, opts UploadOptions) error {
+	if len(source) == 0 {
+		return errors.New("upload source cannot be empty")
+	}
+	if len(dest) == 0 {
+		return errors.New("upload destination cannot be empty")
+	}
+	localPath := source
+	if !filepath.IsAbs(localPath) {
+		localPath = filepath.Join(w.dir, localPath)
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return fmt.Errorf("invalid upload source: %w", err)
+	}
+
+	cmdArgs := []string{"upload"}
+	if opts.Compress {
+		cmdArgs = append(cmdArgs, "--compress")
+	}
+	if opts.Temporary {
+		cmdArgs = append(cmdArgs, "--temporary")
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	cmdArgs = append(cmdArgs, source, dest)
+	if len(opts.Machine) > 0 {
+		if err := validateMachineNames([]string{opts.Machine}); err != nil {
+			return err
+		}
+		cmdArgs = append(cmdArgs, opts.Machine)
+	}
+
+	w.logger.Infof("Uploading %s to vagrant machine", source)
+	return w.execLogOutput(ctx, cmdArgs...)
+}
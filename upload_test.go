@@ -0,0 +1,53 @@
+package vagrantexec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpload(t *testing.T) {
+	source := "testdata/env/Vagrantfile"
+	mockUpload := mockedWrapperFn([]string{"upload", source, "/tmp/Vagrantfile"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockUpload([]byte("Uploading testdata/env/Vagrantfile to /tmp/Vagrantfile"), nil)
+		assert.NoError(t, w.Upload(source, "/tmp/Vagrantfile", UploadOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockUpload(nil, errors.New("runner error"))
+		assert.Error(t, w.Upload(source, "/tmp/Vagrantfile", UploadOptions{}))
+	})
+
+	t.Run("all_options", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"upload", "--compress", "--temporary", "testdata/env", "/tmp/env", "web"})(nil, nil)
+		assert.NoError(t, w.Upload("testdata/env", "/tmp/env", UploadOptions{
+			Compress:  true,
+			Temporary: true,
+			Machine:   "web",
+		}))
+	})
+
+	t.Run("missing_source", func(t *testing.T) {
+		w := mockUpload(nil, nil)
+
+		err := w.Upload("testdata/missing", "/tmp/missing", UploadOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid upload source")
+	})
+
+	t.Run("empty_paths", func(t *testing.T) {
+		w := mockUpload(nil, nil)
+
+		assert.EqualError(t, w.Upload("", "/tmp", UploadOptions{}), "upload source cannot be empty")
+		assert.EqualError(t, w.Upload(source, "", UploadOptions{}), "upload destination cannot be empty")
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockUpload(nil, nil)
+		assert.Error(t, w.Upload(source, "/tmp/Vagrantfile", UploadOptions{Machine: "web&"}))
+	})
+}
@@ -0,0 +1,86 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+)
+
+// validationWarnings matches the message vagrant prints ahead of the warnings found while loading a Vagrantfile.
+var validationWarnings = regexp.MustCompile(`There were warnings and/or errors while loading your Vagrantfile`)
+
+// ValidateOptions configures the behavior of Vagrant.Validate.
+type ValidateOptions struct {
+	// IgnoreProvider skips provider specific validation, which is useful on hosts where the provider is not installed.
+	IgnoreProvider bool
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// ValidationError is returned by Validate when the Vagrantfile is invalid.
+type ValidationError struct {
+	// Message describes the problems found in the Vagrantfile.
+	Message string
+	// Err is the underlying command error.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid Vagrantfile: " + e.Message
+}
+
+// Unwrap returns the underlying command error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the Vagrantfile for syntax and configuration errors without creating any machines. A
+// *ValidationError is returned when the Vagrantfile is invalid.
+func (w wrapper) Validate(opts ValidateOptions) error {
+	return w.ValidateContext(context.Background(), opts)
+}
+
+// ValidateContext is like Validate but includes a context.
+func (w wrapper) ValidateContext(ctx context.Context, opts ValidateOptions) error {
+	_, err := w.ValidateWithWarningsContext(ctx, opts)
+	return err
+}
+
+// ValidateWithWarnings is like Validate but also reports whether vagrant printed warnings while loading an otherwise
+// valid Vagrantfile, e.g. about settings that are deprecated or that a newer version of vagrant interprets
+// differently. Vagrant still exits successfully in that case, so the warnings are detected in its output.
+func (w wrapper) ValidateWithWarnings(opts ValidateOptions) (warnings bool, err error) {
+	return w.ValidateWithWarningsContext(context.Background(), opts)
+}
+
+// ValidateWithWarningsContext is like ValidateWithWarnings but includes a context.
+func (w wrapper) ValidateWithWarningsContext(ctx context.Context, opts ValidateOptions) (warnings bool, err error) {
+	cmdArgs := []string{"validate", "--no-color"}
+	if opts.IgnoreProvider {
+		cmdArgs = append(cmdArgs, "--ignore-provider")
+	}
+	cmdArgs, err = appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := w.exec(ctx, cmdArgs...)
+	var ee command.ExitError
+	if errors.As(err, &ee) {
+		return false, &ValidationError{Message: validationMessage(ee.Stderr()), Err: err}
+	}
+	w.logOutput(out)
+	return validationWarnings.Match(out), err
+}
+
+// validationMessage strips the explanatory preamble vagrant prints ahead of the actual validation errors.
+func validationMessage(stderr string) string {
+	msg := strings.TrimSpace(stderr)
+	if idx := strings.Index(msg, "\n\n"); idx >= 0 {
+		msg = strings.TrimSpace(msg[idx:])
+	}
+	return msg
+}
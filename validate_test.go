@@ -0,0 +1,75 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	mockValidate := mockedWrapperFn([]string{"validate", "--no-color"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockValidate([]byte("Vagrantfile validated successfully."), nil)
+		assert.NoError(t, w.Validate(ValidateOptions{}))
+	})
+
+	t.Run("ignore_provider", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"validate", "--no-color", "--ignore-provider"})(nil, nil)
+		assert.NoError(t, w.Validate(ValidateOptions{IgnoreProvider: true}))
+	})
+
+	t.Run("syntax_error", func(t *testing.T) {
+		stderr := "There is a syntax error in the following Vagrantfile. The syntax error\n" +
+			"message is reproduced below for convenience:\n\n" +
+			"/home/user/project/Vagrantfile:5: syntax error, unexpected end-of-input, expecting `end'\n"
+		w := mockValidate(nil, command.NewExitError("vagrant", 1, stderr))
+
+		err := w.Validate(ValidateOptions{})
+
+		var ve *ValidationError
+		require.True(t, errors.As(err, &ve))
+		assert.Equal(t, "/home/user/project/Vagrantfile:5: syntax error, unexpected end-of-input, expecting `end'", ve.Message)
+		assert.IsType(t, command.ExitError{}, errors.Unwrap(err))
+	})
+
+	t.Run("config_error", func(t *testing.T) {
+		stderr := "There are errors in the configuration of this machine. Please fix\n" +
+			"the following errors and try again:\n\n" +
+			"vm:\n* The 'cpus' setting must be an integer.\n"
+		w := mockValidate(nil, command.NewExitError("vagrant", 1, stderr))
+
+		err := w.Validate(ValidateOptions{})
+
+		var ve *ValidationError
+		require.True(t, errors.As(err, &ve))
+		assert.Equal(t, "vm:\n* The 'cpus' setting must be an integer.", ve.Message)
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		w := mockValidate([]byte("Vagrantfile validated successfully."), nil)
+		warnings, err := w.ValidateWithWarnings(ValidateOptions{})
+		require.NoError(t, err)
+		assert.False(t, warnings)
+
+		w = mockValidate(ioutil.ReadFile("testdata/validate-warnings"))
+		warnings, err = w.ValidateWithWarnings(ValidateOptions{})
+		require.NoError(t, err)
+		assert.True(t, warnings)
+		assert.NoError(t, w.Validate(ValidateOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockValidate(nil, errors.New("runner error"))
+
+		err := w.Validate(ValidateOptions{})
+		require.Error(t, err)
+
+		var ve *ValidationError
+		assert.False(t, errors.As(err, &ve))
+	})
+}
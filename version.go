@@ -0,0 +1,187 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrVersionTooOld is returned by RequireVersion when the installed vagrant is older than the required version.
+var ErrVersionTooOld = errors.New("vagrant version is too old")
+
+// UnknownVersion is returned by VersionLatest when vagrant could not determine the latest version, usually because
+// the update server was unreachable.
+const UnknownVersion = "unknown"
+
+// versionPattern matches vagrant version strings like "2.3.4", "2.2" or "2.4.0.dev". Anything following the patch
+// number, separated by ".", "-" or "+", is treated as a prerelease suffix.
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?(?:[.\-+](.+))?$`)
+
+// VersionInfo is a parsed vagrant version.
+type VersionInfo struct {
+	Major int
+	Minor int
+	Patch int
+	// Prerelease is the suffix of development and prerelease builds, e.g. "dev" for "2.4.0.dev". It is empty for
+	// releases.
+	Prerelease string
+}
+
+// ParseVersion parses a vagrant version string. The patch number defaults to 0 when omitted, so "2.2" is equal to
+// "2.2.0".
+func ParseVersion(version string) (VersionInfo, error) {
+	m := versionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return VersionInfo{}, fmt.Errorf("invalid vagrant version %q", version)
+	}
+
+	var v VersionInfo
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if len(m[3]) > 0 {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	v.Prerelease = m[4]
+	return v, nil
+}
+
+// String returns the version in "major.minor.patch" form, followed by ".prerelease" if there is one.
+func (v VersionInfo) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "." + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is older than, equal to or newer than other. A prerelease is older than the release
+// with the same number, and prereleases of the same release are compared lexically.
+func (v VersionInfo) Compare(other VersionInfo) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	case v.Prerelease < other.Prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// VersionInfo is like Version but returns the parsed version.
+func (w wrapper) VersionInfo() (VersionInfo, error) {
+	return w.VersionInfoContext(context.Background())
+}
+
+// VersionInfoContext is like VersionInfo but includes a context.
+func (w wrapper) VersionInfoContext(ctx context.Context) (VersionInfo, error) {
+	raw, err := w.VersionContext(ctx)
+	if err != nil || w.dryRun {
+		return VersionInfo{}, err
+	}
+	return ParseVersion(raw)
+}
+
+// VersionLatest returns the latest version of vagrant that is available for download. UnknownVersion is returned
+// instead of an error when vagrant could not check for it, for instance because the host is offline.
+func (w wrapper) VersionLatest() (string, error) {
+	return w.VersionLatestContext(context.Background())
+}
+
+// VersionLatestContext is like VersionLatest but includes a context.
+func (w wrapper) VersionLatestContext(ctx context.Context) (string, error) {
+	_, latest, err := w.versions(ctx)
+	return latest, err
+}
+
+// UpdateAvailable checks if a newer version of vagrant than the installed one is available. It returns false when
+// the latest version is unknown.
+func (w wrapper) UpdateAvailable() (bool, error) {
+	return w.UpdateAvailableContext(context.Background())
+}
+
+// UpdateAvailableContext is like UpdateAvailable but includes a context.
+func (w wrapper) UpdateAvailableContext(ctx context.Context) (bool, error) {
+	rawInstalled, rawLatest, err := w.versions(ctx)
+	if err != nil || rawLatest == UnknownVersion {
+		return false, err
+	}
+
+	installed, err := ParseVersion(rawInstalled)
+	if err != nil {
+		return false, err
+	}
+	latest, err := ParseVersion(rawLatest)
+	if err != nil {
+		return false, err
+	}
+	return latest.Compare(installed) > 0, nil
+}
+
+// versions returns the installed and latest versions reported by vagrant version. The latest version is
+// UnknownVersion when it is missing from the output.
+func (w wrapper) versions(ctx context.Context) (installed, latest string, err error) {
+	out, err := w.exec(ctx, "version", "--machine-readable", "--no-color")
+	if err != nil || w.dryRun {
+		return "", UnknownVersion, err
+	}
+	vInfo, err := parseMachineReadable(out)
+	if err != nil {
+		return
+	}
+
+	data, err := pluckEntryData(vInfo, "version-installed")
+	if err != nil {
+		return
+	}
+	installed = data[0]
+
+	latest = UnknownVersion
+	if data, pluckErr := pluckEntryData(vInfo, "version-latest"); pluckErr == nil && len(data[0]) > 0 {
+		latest = data[0]
+	} else {
+		w.logger.Debugf("Latest vagrant version is unknown")
+	}
+	return
+}
+
+// RequireVersion returns an error wrapping ErrVersionTooOld if the installed vagrant is older than min, e.g. "2.2" or
+// "2.2.7". It can be used to check that the features of a method are supported before calling it.
+func (w wrapper) RequireVersion(min string) error {
+	return w.RequireVersionContext(context.Background(), min)
+}
+
+// RequireVersionContext is like RequireVersion but includes a context.
+func (w wrapper) RequireVersionContext(ctx context.Context, min string) error {
+	required, err := ParseVersion(min)
+	if err != nil {
+		return err
+	}
+
+	if w.dryRun {
+		return nil
+	}
+	installed, err := w.VersionInfoContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if installed.Compare(required) < 0 {
+		return fmt.Errorf("%w: %s is installed but %s is required", ErrVersionTooOld, installed, required)
+	}
+	return nil
+}
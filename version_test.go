@@ -0,0 +1,183 @@
+package vagrantexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	testcases := []struct {
+		version  string
+		expected VersionInfo
+	}{
+		{"1.9.8", VersionInfo{Major: 1, Minor: 9, Patch: 8}},
+		{"2.0.4", VersionInfo{Major: 2, Minor: 0, Patch: 4}},
+		{"2.2.5", VersionInfo{Major: 2, Minor: 2, Patch: 5}},
+		{"2.2.19", VersionInfo{Major: 2, Minor: 2, Patch: 19}},
+		{"2.3.7", VersionInfo{Major: 2, Minor: 3, Patch: 7}},
+		{"2.4.1", VersionInfo{Major: 2, Minor: 4, Patch: 1}},
+		{"2.2", VersionInfo{Major: 2, Minor: 2}},
+		{"2.3.4.dev", VersionInfo{Major: 2, Minor: 3, Patch: 4, Prerelease: "dev"}},
+		{"2.4.0.dev+main", VersionInfo{Major: 2, Minor: 4, Prerelease: "dev+main"}},
+		{"2.4.0-beta1", VersionInfo{Major: 2, Minor: 4, Prerelease: "beta1"}},
+	}
+	for _, tc := range testcases {
+		v, err := ParseVersion(tc.version)
+		require.NoError(t, err, tc.version)
+		assert.Equal(t, tc.expected, v, tc.version)
+	}
+
+	for _, invalid := range []string{"", "2", "latest", "2.x.1"} {
+		_, err := ParseVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestVersionInfoString(t *testing.T) {
+	assert.Equal(t, "2.2.5", VersionInfo{Major: 2, Minor: 2, Patch: 5}.String())
+	assert.Equal(t, "2.4.0.dev", VersionInfo{Major: 2, Minor: 4, Prerelease: "dev"}.String())
+}
+
+func TestVersionInfo(t *testing.T) {
+	mockVersion := mockedWrapperFn([]string{"version", "--machine-readable", "--no-color"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockVersion(ioutil.ReadFile("testdata/version"))
+
+		v, err := w.VersionInfo()
+		require.NoError(t, err)
+		assert.Equal(t, VersionInfo{Major: 2, Minor: 2, Patch: 5}, v)
+	})
+
+	t.Run("dev_build", func(t *testing.T) {
+		w := mockVersion([]byte("1561757241,,version-installed,2.3.8.dev\n"), nil)
+
+		v, err := w.VersionInfo()
+		require.NoError(t, err)
+		assert.Equal(t, VersionInfo{Major: 2, Minor: 3, Patch: 8, Prerelease: "dev"}, v)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockVersion(nil, errors.New("runner error"))
+
+		_, err := w.VersionInfo()
+		assert.Error(t, err)
+	})
+}
+
+func TestVersionInfoCompare(t *testing.T) {
+	testcases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"2.2.5", "2.2.5", 0},
+		{"2.2", "2.2.0", 0},
+		{"2.2.5", "2.2.19", -1},
+		{"2.3.0", "2.2.19", 1},
+		{"3.0.0", "2.9.9", 1},
+		{"2.4.0.dev", "2.4.0", -1},
+		{"2.4.0.dev", "2.3.9", 1},
+		{"2.4.0.alpha", "2.4.0.beta", -1},
+	}
+	for _, tc := range testcases {
+		a, err := ParseVersion(tc.a)
+		require.NoError(t, err)
+		b, err := ParseVersion(tc.b)
+		require.NoError(t, err)
+
+		assert.Equal(t, tc.expected, a.Compare(b), "%s vs %s", tc.a, tc.b)
+		assert.Equal(t, -tc.expected, b.Compare(a), "%s vs %s", tc.b, tc.a)
+	}
+}
+
+func TestRequireVersion(t *testing.T) {
+	mockVersion := mockedWrapperFn([]string{"version", "--machine-readable", "--no-color"})
+
+	t.Run("satisfied", func(t *testing.T) {
+		w := mockVersion(ioutil.ReadFile("testdata/version"))
+
+		assert.NoError(t, w.RequireVersion("2.2"))
+		assert.NoError(t, w.RequireVersion("2.2.5"))
+	})
+
+	t.Run("too_old", func(t *testing.T) {
+		w := mockVersion(ioutil.ReadFile("testdata/version"))
+
+		err := w.RequireVersion("2.3.0")
+		assert.True(t, errors.Is(err, ErrVersionTooOld))
+		assert.EqualError(t, err, "vagrant version is too old: 2.2.5 is installed but 2.3.0 is required")
+	})
+
+	t.Run("invalid_min", func(t *testing.T) {
+		w := mockVersion(ioutil.ReadFile("testdata/version"))
+
+		assert.Error(t, w.RequireVersion("two"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockVersion(nil, errors.New("runner error"))
+
+		assert.Error(t, w.RequireVersion("2.2"))
+	})
+}
+
+func TestVersionLatest(t *testing.T) {
+	mockVersion := mockedWrapperFn([]string{"version", "--machine-readable", "--no-color"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockVersion(ioutil.ReadFile("testdata/version-outdated"))
+
+		latest, err := w.VersionLatest()
+		require.NoError(t, err)
+		assert.Equal(t, "2.4.1", latest)
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		w := mockVersion(ioutil.ReadFile("testdata/version-offline"))
+
+		latest, err := w.VersionLatest()
+		require.NoError(t, err)
+		assert.Equal(t, UnknownVersion, latest)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockVersion(nil, errors.New("runner error"))
+
+		_, err := w.VersionLatest()
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateAvailable(t *testing.T) {
+	mockVersion := mockedWrapperFn([]string{"version", "--machine-readable", "--no-color"})
+
+	testcases := []struct {
+		name     string
+		fixture  string
+		expected bool
+	}{
+		{"up_to_date", "testdata/version", false},
+		{"outdated", "testdata/version-outdated", true},
+		{"offline", "testdata/version-offline", false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := mockVersion(ioutil.ReadFile(tc.fixture))
+
+			available, err := w.UpdateAvailable()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, available)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		w := mockVersion(nil, errors.New("runner error"))
+
+		_, err := w.UpdateAvailable()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,83 @@
+package vagrantexec
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultWaitInterval is the polling interval used by WaitForState when none is given.
+const defaultWaitInterval = 5 * time.Second
+
+// WaitError is returned by WaitForState when the context is done before the machine reaches the target state.
+type WaitError struct {
+	// Machine is the name of the machine that was polled.
+	Machine string
+	// Target is the state that was waited for.
+	Target MachineState
+	// LastState is the most recent state reported by vagrant. It is Unknown if no state was observed.
+	LastState MachineState
+	// Err is the context error.
+	Err error
+}
+
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("machine %s did not reach state %s, last state was %s: %v", e.Machine, e.Target, e.LastState, e.Err)
+}
+
+// Unwrap returns the context error.
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForState polls the status of a machine every interval until it reaches the target state. A *WaitError carrying
+// the last observed state is returned if the context is done first, so use a context with a deadline to bound the
+// wait. Errors from the status command are returned immediately. The interval defaults to 5 seconds when it is not
+// positive.
+func (w wrapper) WaitForState(ctx context.Context, machine string, target MachineState, interval time.Duration) error {
+	if err := validateMachineNames([]string{machine}); err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := Unknown
+	for {
+		state, err := w.machineState(ctx, machine)
+		if err != nil {
+			if ctx.Err() != nil {
+				return &WaitError{Machine: machine, Target: target, LastState: last, Err: ctx.Err()}
+			}
+			return err
+		}
+		last = state
+		if state == target {
+			return nil
+		}
+		w.logger.Debugf("Machine %s is %s, waiting for %s", machine, state, target)
+
+		select {
+		case <-ctx.Done():
+			return &WaitError{Machine: machine, Target: target, LastState: last, Err: ctx.Err()}
+		case <-ticker.C:
+		}
+	}
+}
+
+// machineState returns the state of a single machine. Status errors about other machines are ignored.
+func (w wrapper) machineState(ctx context.Context, machine string) (MachineState, error) {
+	statuses, err := w.StatusContext(ctx)
+	for _, status := range statuses {
+		if status.Name == machine {
+			return status.State, nil
+		}
+	}
+	if err != nil {
+		return Unknown, err
+	}
+	return Unknown, fmt.Errorf("machine %s not found", machine)
+}
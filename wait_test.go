@@ -0,0 +1,72 @@
+package vagrantexec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dominodatalab/vagrant-exec/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForState(t *testing.T) {
+	statusArgs := []string{"status", "--machine-readable", "--no-color"}
+	statusOutput := func(state string) command.Response {
+		return command.Response{Output: []byte("1562175814,srv-1,provider-name,virtualbox\n1562175814,srv-1,state," + state + "\n")}
+	}
+
+	t.Run("reached", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(statusOutput("poweroff"), "vagrant", statusArgs...)
+		runner.AddResponse(statusOutput("poweroff"), "vagrant", statusArgs...)
+		runner.AddResponse(statusOutput("running"), "vagrant", statusArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.WaitForState(context.Background(), "srv-1", Running, time.Millisecond)
+		require.NoError(t, err)
+		assert.Len(t, runner.Calls(), 3)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(statusOutput("stopping"), "vagrant", statusArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := w.WaitForState(ctx, "srv-1", PowerOff, 10*time.Millisecond)
+
+		var waitErr *WaitError
+		require.True(t, errors.As(err, &waitErr))
+		assert.Equal(t, Stopping, waitErr.LastState)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, "machine srv-1 did not reach state poweroff, last state was stopping: context deadline exceeded", err.Error())
+	})
+
+	t.Run("machine_not_found", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(statusOutput("running"), "vagrant", statusArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.WaitForState(context.Background(), "srv-2", Running, time.Millisecond)
+		assert.EqualError(t, err, "machine srv-2 not found")
+	})
+
+	t.Run("status_error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("runner error")}, "vagrant", statusArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.WaitForState(context.Background(), "srv-1", Running, time.Millisecond)
+		assert.EqualError(t, err, "runner error")
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := wrapper{executable: binary, runner: &command.MockRunner{}, logger: &recordingLogger{}}
+
+		assert.Error(t, w.WaitForState(context.Background(), "", Running, time.Millisecond))
+	})
+}
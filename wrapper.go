@@ -2,31 +2,211 @@
 package vagrantexec
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/dominodatalab/vagrant-exec/command"
 	log "github.com/sirupsen/logrus"
 )
 
-const binary = "vagrant"
+const (
+	binary = "vagrant"
+
+	// shellMetachars are characters that are not permitted in user-supplied command arguments like machine names.
+	shellMetachars = " \t\r\n;&|$<>()`'\"\\*?[]{}!#~"
+)
+
+// pluginListLine matches the "name (version, location)" summary that plugin list prints for each installed plugin.
+var pluginListLine = regexp.MustCompile(`^(\S+) \((.+), (\w+)\)$`)
+
+// globalCommands are vagrant subcommands that do not operate on a Vagrantfile environment.
+var globalCommands = map[string]bool{
+	"version":       true,
+	"plugin":        true,
+	"box":           true,
+	"global-status": true,
+	"init":          true,
+	"cloud":         true,
+	"connect":       true,
+}
+
+// longRunningCommands are vagrant subcommands that run until they are cancelled.
+var longRunningCommands = map[string]bool{
+	"rsync-auto": true,
+	"share":      true,
+	"connect":    true,
+}
 
 // Vagrant defines the interface for executing Vagrant commands.
+//
+// Methods that run a vagrant command to completion have a Context variant that accepts a context.Context as its first
+// argument. Cancelling the context kills the underlying vagrant process and the method returns an error wrapping
+// ctx.Err(). Long-running commands such as RSyncAuto, ShareStart, Connect and UpEvents, as well as WaitForState, only
+// come in a context form. Methods that do not run vagrant, such as SyncedFolders, CheckInstall, Environment and Close,
+// and ShareStop, which stops a process started by ShareStart, have no Context variant.
+//
+// The value returned by New is safe to call from multiple goroutines; it holds no state that commands modify, so
+// concurrent calls start concurrent vagrant processes. Vagrant itself refuses to run two actions on the same machine
+// at once, so concurrent calls such as Up and Destroy on the same machine fail with an error from vagrant rather than
+// interfering with each other. Use WithSerializedExecution to run commands one at a time instead.
 type Vagrant interface {
-	Up() error
-	Halt() error
-	Destroy() error
+	Init(box string, opts InitOptions) error
+	InitContext(ctx context.Context, box string, opts InitOptions) error
+	Up(machines ...string) error
+	UpContext(ctx context.Context, machines ...string) error
+	UpWithOptions(opts UpOptions) error
+	UpWithOptionsContext(ctx context.Context, opts UpOptions) error
+	UpWithResult(opts UpOptions) (*UpResult, error)
+	UpWithResultContext(ctx context.Context, opts UpOptions) (*UpResult, error)
+	EnsureUp(opts UpOptions) (*EnsureUpResult, error)
+	EnsureUpContext(ctx context.Context, opts UpOptions) (*EnsureUpResult, error)
+	UpEvents(ctx context.Context, machines ...string) (<-chan ProvisionEvent, error)
+	Halt(machines ...string) error
+	HaltContext(ctx context.Context, machines ...string) error
+	HaltWithOptions(opts HaltOptions) error
+	HaltWithOptionsContext(ctx context.Context, opts HaltOptions) error
+	Destroy(machines ...string) error
+	DestroyContext(ctx context.Context, machines ...string) error
+	DestroyWithOptions(opts DestroyOptions) error
+	DestroyWithOptionsContext(ctx context.Context, opts DestroyOptions) error
+	Teardown(opts TeardownOptions) error
+	TeardownContext(ctx context.Context, opts TeardownOptions) error
+	Suspend(machines ...string) error
+	SuspendContext(ctx context.Context, machines ...string) error
+	Resume(machines ...string) error
+	ResumeContext(ctx context.Context, machines ...string) error
+	Reload(opts ReloadOptions) error
+	ReloadContext(ctx context.Context, opts ReloadOptions) error
+	Provision(opts ProvisionOptions) error
+	ProvisionContext(ctx context.Context, opts ProvisionOptions) error
+	RSync(machines ...string) error
+	RSyncContext(ctx context.Context, machines ...string) error
+	RSyncAuto(ctx context.Context, machines ...string) error
+	Upload(source, dest string, opts UploadOptions) error
+	UploadContext(ctx context.Context, source, dest string, opts UploadOptions) error
+	Download(guestPath, hostPath string, opts DownloadOptions) error
+	DownloadContext(ctx context.Context, guestPath, hostPath string, opts DownloadOptions) error
+	ShareStart(ctx context.Context, opts ShareOptions) (shareName string, err error)
+	ShareStop(shareName string) error
+	Connect(ctx context.Context, shareName string, opts ConnectOptions) error
+	Package(opts PackageOptions) error
+	PackageContext(ctx context.Context, opts PackageOptions) error
+	Validate(opts ValidateOptions) error
+	ValidateContext(ctx context.Context, opts ValidateOptions) error
+	ValidateWithWarnings(opts ValidateOptions) (warnings bool, err error)
+	ValidateWithWarningsContext(ctx context.Context, opts ValidateOptions) (warnings bool, err error)
 	Status() (statusList []MachineStatus, err error)
+	StatusContext(ctx context.Context) (statusList []MachineStatus, err error)
+	StatusByState(states ...MachineState) ([]MachineStatus, error)
+	StatusByStateContext(ctx context.Context, states ...MachineState) ([]MachineStatus, error)
+	CombinedStatus() ([]CombinedMachineStatus, error)
+	CombinedStatusContext(ctx context.Context) ([]CombinedMachineStatus, error)
+	GlobalStatus(opts GlobalStatusOptions) ([]GlobalMachineStatus, error)
+	GlobalStatusContext(ctx context.Context, opts GlobalStatusOptions) ([]GlobalMachineStatus, error)
+	Prune() error
+	PruneContext(ctx context.Context) error
+	DestroyByID(id string) error
+	DestroyByIDContext(ctx context.Context, id string) error
 	Version() (string, error)
+	VersionContext(ctx context.Context) (string, error)
+	VersionInfo() (VersionInfo, error)
+	VersionInfoContext(ctx context.Context) (VersionInfo, error)
+	VersionLatest() (string, error)
+	VersionLatestContext(ctx context.Context) (string, error)
+	UpdateAvailable() (bool, error)
+	UpdateAvailableContext(ctx context.Context) (bool, error)
+	RequireVersion(min string) error
+	RequireVersionContext(ctx context.Context, min string) error
+	AvailableProviders() ([]string, error)
+	AvailableProvidersContext(ctx context.Context) ([]string, error)
+	ProviderUsable(machine string) (bool, error)
+	ProviderUsableContext(ctx context.Context, machine string) (bool, error)
+	LibvirtVolumes(opts LibvirtVolumeOptions) ([]Volume, error)
+	LibvirtVolumesContext(ctx context.Context, opts LibvirtVolumeOptions) ([]Volume, error)
 	SSH(nameOrID, command string) (cmdOutput string, err error)
+	SSHContext(ctx context.Context, nameOrID, command string) (cmdOutput string, err error)
+	SSHWithOptions(command string, opts SSHOptions) (cmdOutput string, err error)
+	SSHWithOptionsContext(ctx context.Context, command string, opts SSHOptions) (cmdOutput string, err error)
+	GuestExec(machine, cmd string) (string, error)
+	GuestExecContext(ctx context.Context, machine, cmd string) (string, error)
+	DockerExec(machine string, cmd ...string) (cmdOutput string, err error)
+	DockerExecContext(ctx context.Context, machine string, cmd ...string) (cmdOutput string, err error)
+	SSHConfig(machine string) (*SSHInfo, error)
+	SSHConfigContext(ctx context.Context, machine string) (*SSHInfo, error)
+	SSHPort(machine ...string) (int, error)
+	SSHPortContext(ctx context.Context, machine ...string) (int, error)
+	SyncedFolders(machine ...string) ([]SyncedFolder, error)
+	Port(machine string) ([]PortMapping, error)
+	PortContext(ctx context.Context, machine string) ([]PortMapping, error)
+	SnapshotSave(name string) error
+	SnapshotSaveContext(ctx context.Context, name string) error
+	SnapshotRestore(name string) error
+	SnapshotRestoreContext(ctx context.Context, name string) error
+	SnapshotList() ([]string, error)
+	SnapshotListContext(ctx context.Context) ([]string, error)
+	SnapshotDelete(name string) error
+	SnapshotDeleteContext(ctx context.Context, name string) error
+	SnapshotPush() error
+	SnapshotPushContext(ctx context.Context) error
+	SnapshotPop(opts SnapshotPopOptions) error
+	SnapshotPopContext(ctx context.Context, opts SnapshotPopOptions) error
+	BoxList() ([]Box, error)
+	BoxListContext(ctx context.Context) ([]Box, error)
+	BoxAdd(box Box) error
+	BoxAddContext(ctx context.Context, box Box) error
+	BoxAddWithOptions(box Box, opts BoxAddOptions) error
+	BoxAddWithOptionsContext(ctx context.Context, box Box, opts BoxAddOptions) error
+	BoxRemove(name string) error
+	BoxRemoveContext(ctx context.Context, name string) error
+	BoxUpdate() error
+	BoxUpdateContext(ctx context.Context) error
+	BoxOutdated() (bool, error)
+	BoxOutdatedContext(ctx context.Context) (bool, error)
+	BoxOutdatedGlobal() ([]Box, error)
+	BoxOutdatedGlobalContext(ctx context.Context) ([]Box, error)
+	CloudLogin(token string) error
+	CloudLoginContext(ctx context.Context, token string) error
+	CloudLogout() error
+	CloudLogoutContext(ctx context.Context) error
 	PluginList() (plugins []Plugin, err error)
+	PluginListContext(ctx context.Context) (plugins []Plugin, err error)
 	PluginInstall(plugin Plugin) error
+	PluginInstallContext(ctx context.Context, plugin Plugin) error
+	PluginInstallWithOptions(plugin Plugin, opts PluginInstallOptions) error
+	PluginInstallWithOptionsContext(ctx context.Context, plugin Plugin, opts PluginInstallOptions) error
+	PluginUninstall(name string) error
+	PluginUninstallContext(ctx context.Context, name string) error
+	PluginUpdate(name string) error
+	PluginUpdateContext(ctx context.Context, name string) error
+	PluginRepair() error
+	PluginRepairContext(ctx context.Context) error
+	PluginExpunge(reinstall bool) error
+	PluginExpungeContext(ctx context.Context, reinstall bool) error
+	Run(args ...string) ([]byte, error)
+	RunContext(ctx context.Context, args ...string) ([]byte, error)
+	Environment(dir string) Vagrant
+	Close() error
 
 	// helper functions
 
 	IsPluginInstalled(plugin Plugin) (installed bool, err error)
+	IsPluginInstalledContext(ctx context.Context, plugin Plugin) (installed bool, err error)
+	IsCreated(machines ...string) (created bool, err error)
+	IsCreatedContext(ctx context.Context, machines ...string) (created bool, err error)
+	WaitForState(ctx context.Context, machine string, target MachineState, interval time.Duration) error
+	CheckInstall() error
 }
 
 // Plugin encapsulates Vagrant plugin metadata.
@@ -36,64 +216,476 @@ type Plugin struct {
 	Location string
 }
 
+// PluginInstallOptions configures the behavior of Vagrant.PluginInstallWithOptions.
+type PluginInstallOptions struct {
+	// Sources are additional RubyGems sources, such as an internal mirror, to install the plugin and its dependencies
+	// from. They must be http or https URLs.
+	Sources []string
+	// CleanSources removes the default sources, including rubygems.org, so that only Sources are used. This is
+	// required for hosts without internet access.
+	CleanSources bool
+}
+
+// UpOptions configures the behavior of Vagrant.UpWithOptions.
+type UpOptions struct {
+	// Provider selects the provider used to create the machines (e.g. virtualbox, docker). The Vagrantfile or
+	// VAGRANT_DEFAULT_PROVIDER decides when empty.
+	Provider string
+	// Parallel enables or disables bringing up machines in parallel if the provider supports it. The flag is omitted
+	// when nil so that the provider default applies.
+	Parallel *bool
+	// Provision forces provisioners to run or be skipped. The flag is omitted when nil so that provisioners only run
+	// on the first up.
+	Provision *bool
+	// DestroyOnError controls whether vagrant destroys a newly created machine when up fails, e.g. because a
+	// provisioner failed. Set it to false to keep the broken machine for debugging. The flag is omitted when nil so
+	// that vagrant's default, destroying the machine, applies.
+	DestroyOnError *bool
+	// Machines limits the command to the named machines. All machines are brought up when empty.
+	Machines []string
+	// OnBoxProgress is called with the name of the box being downloaded and the percentage downloaded so far, from 0
+	// to 100, as vagrant reports it. 100 is always reported once the download finishes and vagrant starts importing
+	// the box. When set, up runs with machine-readable output, which is what a writer configured with WithOutput
+	// receives. It is not used by UpWithResult.
+	OnBoxProgress func(box string, pct int)
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags, for flags such as --debug that have no field
+	// here. Each argument must be a flag, with any value attached as in --flag=value, so that it cannot be taken for a
+	// subcommand or a machine name; "--" is rejected as well. The ExtraArgs of the other options structs follow the
+	// same rules.
+	ExtraArgs []string
+}
+
+// UpResult describes the machines handled by Vagrant.UpWithResult.
+type UpResult struct {
+	// Booted lists the machines that were started by the command.
+	Booted []string
+	// Unchanged lists the machines that were not booted, usually because they were already running.
+	Unchanged []string
+}
+
+// SSHOptions configures the behavior of Vagrant.SSHWithOptions.
+type SSHOptions struct {
+	// TTY runs the command with a pseudo-terminal, which interactive programs and tools that check isatty need. The
+	// captured output may then contain terminal control characters and carriage returns.
+	TTY bool
+	// Machine is the name or id of the machine to run the command on. It can be empty if you only have one VM defined.
+	Machine string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// HaltOptions configures the behavior of Vagrant.HaltWithOptions.
+type HaltOptions struct {
+	// Force powers off the machines immediately instead of shutting down the guest operating system gracefully.
+	Force bool
+	// Machines limits the command to the named machines. All machines are halted when empty.
+	Machines []string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// DestroyOptions configures the behavior of Vagrant.DestroyWithOptions.
+type DestroyOptions struct {
+	// Parallel enables or disables destroying machines in parallel if the provider supports it. The flag is omitted
+	// when nil so that the provider default applies.
+	Parallel *bool
+	// Graceful attempts a clean shutdown of the guest before destroying it instead of powering it off abruptly. The
+	// --force flag is always passed as well, so the command never prompts for confirmation.
+	Graceful bool
+	// Prune removes invalid entries from the machine index after a successful destroy. See Vagrant.Prune.
+	Prune bool
+	// Machines limits the command to the named machines. All machines are destroyed when empty.
+	Machines []string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// ReloadOptions configures the behavior of Vagrant.Reload.
+type ReloadOptions struct {
+	// Provision forces the provisioners to run during the reload.
+	Provision bool
+	// Machines limits the reload to the named machines. All machines are reloaded when empty.
+	Machines []string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
+// ProvisionOptions configures the behavior of Vagrant.Provision.
+type ProvisionOptions struct {
+	// ProvisionWith limits provisioning to the named provisioners (e.g. shell, ansible). All provisioners run when
+	// empty.
+	ProvisionWith []string
+	// Machines limits provisioning to the named machines. All machines are provisioned when empty.
+	Machines []string
+	// ExtraArgs are passed to vagrant verbatim after the modeled flags. See UpOptions.ExtraArgs.
+	ExtraArgs []string
+}
+
 // wrapper is the default implementation of the Vagrant Interface.
 type wrapper struct {
-	executable string
-	runner     command.Runner
-	logger     log.FieldLogger
+	executable    string
+	dir           string
+	vagrantfile   string
+	env           map[string]string
+	envOverride   bool
+	runner        command.Runner
+	logger        Logger
+	stdout        io.Writer
+	stderr        io.Writer
+	stdin         io.Reader
+	retry         *retryPolicy
+	timeouts      map[string]time.Duration
+	dryRun        bool
+	combined      bool
+	structured    bool
+	hooks         []ExecHook
+	observer      ExecObserver
+	redact        map[string]bool
+	acceptedCodes map[string][]int
+	shares        *shareRegistry
+	procs         *processRegistry
+	serialize     chan struct{}
+	hostTool      bool
+	discardOutput bool
 }
 
 // New creates a new Vagrant CLI wrapper targeting a directory where a Vagrantfile should exist.
-func New(vagrantfileDir string, debug bool) Vagrant {
+func New(vagrantfileDir string, debug bool, opts ...Option) Vagrant {
 	if len(vagrantfileDir) == 0 {
 		panic("vagrantfile dir cannot be empty")
 	}
-	runner := command.ShellRunner{
-		Dir: vagrantfileDir,
-	}
 
 	logger := log.New()
 	if debug {
 		logger.SetLevel(log.DebugLevel)
 	}
 
-	return wrapper{
+	w := wrapper{
 		executable: binary,
+		dir:        vagrantfileDir,
 		logger:     logger,
-		runner:     runner,
+		shares:     &shareRegistry{},
+		procs:      &processRegistry{},
+		observer:   NopObserver{},
+	}
+	for _, opt := range opts {
+		opt(&w)
+	}
+	if w.runner == nil {
+		w.runner = command.ShellRunner{
+			Dir:         w.dir,
+			Env:         w.environ(),
+			OverrideEnv: w.envOverride,
+			Stdin:       w.stdin,
+		}
+	}
+	return w
+}
+
+// Up creates and configures guest machines according to your Vagrantfile. All machines are targeted when no machine
+// names are given.
+func (w wrapper) Up(machines ...string) error {
+	return w.UpContext(context.Background(), machines...)
+}
+
+// UpContext is like Up but includes a context.
+func (w wrapper) UpContext(ctx context.Context, machines ...string) error {
+	return w.UpWithOptionsContext(ctx, UpOptions{Machines: machines})
+}
+
+// UpWithOptions is like Up but allows selecting the provider and controlling parallelism and provisioning.
+func (w wrapper) UpWithOptions(opts UpOptions) error {
+	return w.UpWithOptionsContext(context.Background(), opts)
+}
+
+// UpWithOptionsContext is like UpWithOptions but includes a context.
+func (w wrapper) UpWithOptionsContext(ctx context.Context, opts UpOptions) error {
+	cmdArgs, err := upArgs(opts)
+	if err != nil {
+		return err
+	}
+
+	w.logger.Infof("Starting vagrant environment")
+	if opts.OnBoxProgress != nil {
+		return w.upWithBoxProgress(ctx, cmdArgs, opts.OnBoxProgress)
 	}
+	return w.execLogOutput(ctx, append([]string{"up"}, cmdArgs...)...)
 }
 
-// Up creates and configures guest machines according to your Vagrantfile.
-func (w wrapper) Up() error {
-	w.logger.Info("Starting vagrant environment")
-	return w.execLogOutput("up")
+// UpWithResult is like UpWithOptions but reports which machines were booted by the command and which were left
+// as they were, e.g. because they were already running.
+func (w wrapper) UpWithResult(opts UpOptions) (*UpResult, error) {
+	return w.UpWithResultContext(context.Background(), opts)
 }
 
-// Halt will gracefully shut down the guest operating system and power down the guest machine.
-func (w wrapper) Halt() error {
-	w.logger.Info("Stopping vagrant machines")
-	return w.execLogOutput("halt")
+// UpWithResultContext is like UpWithResult but includes a context. The machines handled before a failure are
+// reported along with the error.
+func (w wrapper) UpWithResultContext(ctx context.Context, opts UpOptions) (*UpResult, error) {
+	cmdArgs, err := upArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	w.logger.Infof("Starting vagrant environment")
+	out, err := w.exec(ctx, append([]string{"up", "--machine-readable", "--no-color"}, cmdArgs...)...)
+	entries, _ := parseMachineReadable(out)
+
+	var machines []string
+	booted := map[string]bool{}
+	for _, entry := range entries {
+		event, ok := parseProvisionEvent(entry)
+		if !ok || len(event.Machine) == 0 {
+			continue
+		}
+		switch event.Type {
+		case ActionStartedEvent:
+			if !slices.Contains(machines, event.Machine) {
+				machines = append(machines, event.Machine)
+			}
+		case MachineBootedEvent:
+			booted[event.Machine] = true
+		case OutputEvent, ProvisionerStartedEvent:
+			if w.stdout == nil {
+				w.logger.Infof("%s", event.Message)
+			}
+		}
+	}
+
+	result := &UpResult{}
+	for _, machine := range machines {
+		if booted[machine] {
+			result.Booted = append(result.Booted, machine)
+		} else {
+			result.Unchanged = append(result.Unchanged, machine)
+		}
+	}
+	return result, err
+}
+
+// upArgs converts UpOptions into arguments for the up command.
+func upArgs(opts UpOptions) ([]string, error) {
+	if err := validateMachineNames(opts.Machines); err != nil {
+		return nil, err
+	}
+	var cmdArgs []string
+
+	if len(opts.Provider) > 0 {
+		if strings.ContainsAny(opts.Provider, shellMetachars) {
+			return nil, fmt.Errorf("invalid provider %q", opts.Provider)
+		}
+		cmdArgs = append(cmdArgs, "--provider", opts.Provider)
+	}
+	if opts.Parallel != nil {
+		cmdArgs = append(cmdArgs, boolFlag("parallel", *opts.Parallel))
+	}
+	if opts.Provision != nil {
+		cmdArgs = append(cmdArgs, boolFlag("provision", *opts.Provision))
+	}
+	if opts.DestroyOnError != nil {
+		cmdArgs = append(cmdArgs, boolFlag("destroy-on-error", *opts.DestroyOnError))
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return nil, err
+	}
+	return append(cmdArgs, opts.Machines...), nil
+}
+
+// Halt will gracefully shut down the guest operating system and power down the guest machine. All machines are
+// targeted when no machine names are given.
+func (w wrapper) Halt(machines ...string) error {
+	return w.HaltContext(context.Background(), machines...)
+}
+
+// HaltContext is like Halt but includes a context.
+func (w wrapper) HaltContext(ctx context.Context, machines ...string) error {
+	return w.HaltWithOptionsContext(ctx, HaltOptions{Machines: machines})
+}
+
+// HaltWithOptions is like Halt but allows forcing the machines to power off, which is equivalent to pulling the power.
+func (w wrapper) HaltWithOptions(opts HaltOptions) error {
+	return w.HaltWithOptionsContext(context.Background(), opts)
+}
+
+// HaltWithOptionsContext is like HaltWithOptions but includes a context.
+func (w wrapper) HaltWithOptionsContext(ctx context.Context, opts HaltOptions) error {
+	if err := validateMachineNames(opts.Machines); err != nil {
+		return err
+	}
+	cmdArgs := []string{"halt"}
+
+	if opts.Force {
+		cmdArgs = append(cmdArgs, "--force")
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	cmdArgs = append(cmdArgs, opts.Machines...)
+
+	w.logger.Infof("Stopping vagrant machines")
+	return w.execLogOutput(ctx, cmdArgs...)
 }
 
 // Destroy stops the running guest machines and destroys all of the resources created during the creation process.
-func (w wrapper) Destroy() error {
-	w.logger.Info("Deleting vagrant machines")
-	return w.execLogOutput("destroy", "--force")
+// All machines are targeted when no machine names are given.
+func (w wrapper) Destroy(machines ...string) error {
+	return w.DestroyContext(context.Background(), machines...)
+}
+
+// DestroyContext is like Destroy but includes a context.
+func (w wrapper) DestroyContext(ctx context.Context, machines ...string) error {
+	return w.DestroyWithOptionsContext(ctx, DestroyOptions{Machines: machines})
 }
 
-// Status reports the status of the machines Vagrant is managing.
-func (w wrapper) Status() (statuses []MachineStatus, err error) {
-	out, err := w.exec("status", "--machine-readable")
+// DestroyWithOptions is like Destroy but allows controlling parallelism. The command always runs with --force so that
+// it never waits for confirmation.
+func (w wrapper) DestroyWithOptions(opts DestroyOptions) error {
+	return w.DestroyWithOptionsContext(context.Background(), opts)
+}
+
+// DestroyWithOptionsContext is like DestroyWithOptions but includes a context.
+func (w wrapper) DestroyWithOptionsContext(ctx context.Context, opts DestroyOptions) error {
+	if err := validateMachineNames(opts.Machines); err != nil {
+		return err
+	}
+	cmdArgs := []string{"destroy", "--force"}
+
+	if opts.Graceful {
+		cmdArgs = append(cmdArgs, "--graceful")
+	}
+	if opts.Parallel != nil {
+		cmdArgs = append(cmdArgs, boolFlag("parallel", *opts.Parallel))
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
 	if err != nil {
-		return
+		return err
+	}
+	cmdArgs = append(cmdArgs, opts.Machines...)
+
+	w.logger.Infof("Deleting vagrant machines")
+	if err := w.execLogOutput(ctx, cmdArgs...); err != nil {
+		return err
+	}
+	if opts.Prune {
+		if err := w.PruneContext(ctx); err != nil {
+			return fmt.Errorf("machines destroyed but the machine index could not be pruned: %w", err)
+		}
+	}
+	return nil
+}
+
+// Suspend saves the state of the guest machines and stops them instead of shutting them down. All machines are
+// targeted when no machine names are given.
+func (w wrapper) Suspend(machines ...string) error {
+	return w.SuspendContext(context.Background(), machines...)
+}
+
+// SuspendContext is like Suspend but includes a context.
+func (w wrapper) SuspendContext(ctx context.Context, machines ...string) error {
+	if err := validateMachineNames(machines); err != nil {
+		return err
+	}
+
+	w.logger.Infof("Suspending vagrant machines")
+	return w.execLogOutput(ctx, append([]string{"suspend"}, machines...)...)
+}
+
+// Resume brings up guest machines that were previously suspended. All machines are targeted when no machine names are
+// given.
+func (w wrapper) Resume(machines ...string) error {
+	return w.ResumeContext(context.Background(), machines...)
+}
+
+// ResumeContext is like Resume but includes a context.
+func (w wrapper) ResumeContext(ctx context.Context, machines ...string) error {
+	if err := validateMachineNames(machines); err != nil {
+		return err
+	}
+
+	w.logger.Infof("Resuming vagrant machines")
+	return w.execLogOutput(ctx, append([]string{"resume"}, machines...)...)
+}
+
+// Reload restarts guest machines so that changes made to the Vagrantfile take effect.
+func (w wrapper) Reload(opts ReloadOptions) error {
+	return w.ReloadContext(context.Background(), opts)
+}
+
+// ReloadContext is like Reload but includes a context.
+func (w wrapper) ReloadContext(ctx context.Context, opts ReloadOptions) error {
+	if err := validateMachineNames(opts.Machines); err != nil {
+		return err
+	}
+	cmdArgs := []string{"reload"}
+
+	if opts.Provision {
+		cmdArgs = append(cmdArgs, "--provision")
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	cmdArgs = append(cmdArgs, opts.Machines...)
+
+	w.logger.Infof("Reloading vagrant machines")
+	return w.execLogOutput(ctx, cmdArgs...)
+}
+
+// Provision runs the configured provisioners against running guest machines.
+func (w wrapper) Provision(opts ProvisionOptions) error {
+	return w.ProvisionContext(context.Background(), opts)
+}
+
+// ProvisionContext is like Provision but includes a context.
+func (w wrapper) ProvisionContext(ctx context.Context, opts ProvisionOptions) error {
+	if err := validateMachineNames(opts.Machines); err != nil {
+		return err
+	}
+	cmdArgs := []string{"provision"}
+
+	if len(opts.ProvisionWith) > 0 {
+		for _, provisioner := range opts.ProvisionWith {
+			if len(strings.TrimSpace(provisioner)) == 0 || strings.Contains(provisioner, ",") {
+				return fmt.Errorf("invalid provisioner name %q", provisioner)
+			}
+		}
+		cmdArgs = append(cmdArgs, "--provision-with", strings.Join(opts.ProvisionWith, ","))
+	}
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return err
+	}
+	cmdArgs = append(cmdArgs, opts.Machines...)
+
+	w.logger.Infof("Provisioning vagrant machines")
+	return w.execLogOutput(ctx, cmdArgs...)
+}
+
+// Status reports the status of the machines Vagrant is managing, in the order vagrant lists them. When the output for
+// some machines cannot be parsed, the statuses of the others are returned along with an error describing the
+// failures. A *MachineReadableError is returned when vagrant reports an error, such as an invalid Vagrantfile.
+func (w wrapper) Status() ([]MachineStatus, error) {
+	return w.StatusContext(context.Background())
+}
+
+// StatusContext is like Status but includes a context. When a provider daemon such as libvirtd is down, vagrant may
+// hang instead of failing, so use a context with a short deadline to bound the call. If vagrant does fail, the error
+// can be checked with IsProviderUnreachable.
+func (w wrapper) StatusContext(ctx context.Context) (statuses []MachineStatus, err error) {
+	out, err := w.exec(ctx, "status", "--machine-readable", "--no-color")
+	machineInfo, parseErr := parseMachineReadable(out)
+	if mrErr := machineReadableError(machineInfo, err); mrErr != nil {
+		return nil, mrErr
 	}
-	machineInfo, err := parseMachineReadable(out)
 	if err != nil {
 		return
 	}
+	errs := []error{parseErr}
 
+	var names []string
 	statusMap := map[string]*MachineStatus{}
+	hasState := map[string]bool{}
 	for _, entry := range machineInfo {
 		if len(entry.target) == 0 {
 			continue // skip when no target specified
@@ -104,55 +696,105 @@ func (w wrapper) Status() (statuses []MachineStatus, err error) {
 		if !ok {
 			status = &MachineStatus{Name: entry.target}
 			statusMap[entry.target] = status
+			names = append(names, entry.target)
 		}
 
 		switch entry.mType { // populate status fields
+		case "machine-id":
+			status.ID = entry.data[0]
+		case "metadata": // also reports the provider, which is used when no provider-name entry is printed
+			if len(entry.data) > 1 && entry.data[0] == "provider" && len(status.Provider) == 0 {
+				status.Provider = entry.data[1]
+			}
 		case "provider-name":
 			status.Provider = entry.data[0]
 		case "state":
 			status.State = ToMachineState(entry.data[0])
+			status.LastUpdated = entry.timestamp
+			hasState[entry.target] = true
 		}
 	}
 
-	for _, st := range statusMap {
-		statuses = append(statuses, *st)
+	for _, name := range names {
+		if !hasState[name] {
+			errs = append(errs, fmt.Errorf("no state reported for machine %s", name))
+			continue
+		}
+		statuses = append(statuses, *statusMap[name])
 	}
-	return statuses, nil
+	return statuses, errors.Join(errs...)
+}
+
+// StatusByState is like Status but only reports the machines in one of the given states, e.g. Running or NotCreated.
+func (w wrapper) StatusByState(states ...MachineState) ([]MachineStatus, error) {
+	return w.StatusByStateContext(context.Background(), states...)
+}
+
+// StatusByStateContext is like StatusByState but includes a context.
+func (w wrapper) StatusByStateContext(ctx context.Context, states ...MachineState) ([]MachineStatus, error) {
+	statuses, err := w.StatusContext(ctx)
+	return FilterStatuses(statuses, states...), err
 }
 
 // Version displays the current version of Vagrant you have installed.
-func (w wrapper) Version() (version string, err error) {
-	out, err := w.exec("version", "--machine-readable")
-	if err != nil {
-		return
-	}
-	vInfo, err := parseMachineReadable(out)
-	if err != nil {
-		return
-	}
-	data, err := pluckEntryData(vInfo, "version-installed")
-	if err != nil {
-		return
-	}
+func (w wrapper) Version() (string, error) {
+	return w.VersionContext(context.Background())
+}
 
-	return data[0], err
+// VersionContext is like Version but includes a context.
+func (w wrapper) VersionContext(ctx context.Context) (version string, err error) {
+	version, _, err = w.versions(ctx)
+	return
 }
 
 // SSH executes a command on a Vagrant machine via SSH and returns the stdout/stderr output.
-// You can use an empty string as the nameOrID if you only have one VM defined in your Vagrantfile.
+// You can use an empty string as the nameOrID if you only have one VM defined in your Vagrantfile. If the command
+// fails on a machine backed by the docker provider, the error suggests using DockerExec instead. An *SSHNotReadyError is
+// returned when the guest does not accept SSH connections yet.
 func (w wrapper) SSH(nameOrID, command string) (string, error) {
-	cmdArgs := []string{"ssh", "--no-tty", "--command", command}
-	if len(nameOrID) > 0 {
-		cmdArgs = append(cmdArgs, nameOrID)
+	return w.SSHContext(context.Background(), nameOrID, command)
+}
+
+// SSHContext is like SSH but includes a context.
+func (w wrapper) SSHContext(ctx context.Context, nameOrID, command string) (string, error) {
+	return w.SSHWithOptionsContext(ctx, command, SSHOptions{Machine: nameOrID})
+}
+
+// SSHWithOptions is like SSH but allows running the command with a pseudo-terminal.
+func (w wrapper) SSHWithOptions(command string, opts SSHOptions) (string, error) {
+	return w.SSHWithOptionsContext(context.Background(), command, opts)
+}
+
+// SSHWithOptionsContext is like SSHWithOptions but includes a context.
+func (w wrapper) SSHWithOptionsContext(ctx context.Context, command string, opts SSHOptions) (string, error) {
+	cmdArgs := []string{"ssh"}
+	if !opts.TTY {
+		cmdArgs = append(cmdArgs, "--no-tty")
+	}
+	cmdArgs = append(cmdArgs, "--command", command)
+	cmdArgs, err := appendExtraArgs(cmdArgs, opts.ExtraArgs)
+	if err != nil {
+		return "", err
+	}
+	if len(opts.Machine) > 0 {
+		if err := validateMachineNames([]string{opts.Machine}); err != nil {
+			return "", err
+		}
+		cmdArgs = append(cmdArgs, opts.Machine)
 	}
 
-	out, err := w.exec(cmdArgs...)
-	return string(out), err
+	out, err := w.exec(ctx, cmdArgs...)
+	return string(out), w.dockerSSHError(ctx, opts.Machine, sshNotReadyError(opts.Machine, err))
 }
 
 // PluginList returns a list of all installed plugins, their versions and install locations.
-func (w wrapper) PluginList() (plugins []Plugin, err error) {
-	out, err := w.exec("plugin", "list", "--machine-readable")
+func (w wrapper) PluginList() ([]Plugin, error) {
+	return w.PluginListContext(context.Background())
+}
+
+// PluginListContext is like PluginList but includes a context.
+func (w wrapper) PluginListContext(ctx context.Context) (plugins []Plugin, err error) {
+	out, err := w.exec(ctx, "plugin", "list", "--machine-readable", "--no-color")
 	if err != nil {
 		return
 	}
@@ -160,29 +802,46 @@ func (w wrapper) PluginList() (plugins []Plugin, err error) {
 	if err != nil {
 		return
 	}
-	pluginMetadataExtractor := regexp.MustCompile(`^([\w-]+)\s\((.*)%!\(VAGRANT_COMMA\)\s([a-z]+)\)$`)
 	for _, entry := range pluginInfo {
-		if entry.mType == "ui" { // "ui" type may contain combined name/version data
-			combinedData := entry.data[1]
-			if strings.Contains(combinedData, "No plugins installed") {
-				break
-			}
+		if entry.mType != "ui" || len(entry.data) < 2 { // "ui" type may contain combined name/version data
+			continue
+		}
+		combinedData := entry.data[1]
+		if strings.Contains(combinedData, "No plugins installed") {
+			break
+		}
 
-			if ms := pluginMetadataExtractor.FindAllStringSubmatch(combinedData, -1); ms != nil {
-				matches := ms[0][1:] // lens into captures
-				plugins = append(plugins, Plugin{
-					Name:     matches[0],
-					Version:  matches[1],
-					Location: matches[2],
-				})
-			}
+		matches := pluginListLine.FindStringSubmatch(combinedData)
+		if matches == nil {
+			w.logger.Debugf("Skipping plugin list line: %s", combinedData)
+			continue
 		}
+		plugins = append(plugins, Plugin{
+			Name:     matches[1],
+			Version:  matches[2],
+			Location: matches[3],
+		})
 	}
 	return
 }
 
 // PluginInstall installs a plugin with the given name or file path.
 func (w wrapper) PluginInstall(plugin Plugin) error {
+	return w.PluginInstallContext(context.Background(), plugin)
+}
+
+// PluginInstallContext is like PluginInstall but includes a context.
+func (w wrapper) PluginInstallContext(ctx context.Context, plugin Plugin) error {
+	return w.PluginInstallWithOptionsContext(ctx, plugin, PluginInstallOptions{})
+}
+
+// PluginInstallWithOptions is like PluginInstall but allows installing from custom gem sources.
+func (w wrapper) PluginInstallWithOptions(plugin Plugin, opts PluginInstallOptions) error {
+	return w.PluginInstallWithOptionsContext(context.Background(), plugin, opts)
+}
+
+// PluginInstallWithOptionsContext is like PluginInstallWithOptions but includes a context.
+func (w wrapper) PluginInstallWithOptionsContext(ctx context.Context, plugin Plugin, opts PluginInstallOptions) error {
 	if len(plugin.Name) == 0 {
 		return errors.New("plugin must have a name")
 	}
@@ -194,20 +853,107 @@ func (w wrapper) PluginInstall(plugin Plugin) error {
 	if plugin.Location == "local" {
 		cmdArgs = append(cmdArgs, "--local")
 	}
+	if opts.CleanSources {
+		if len(opts.Sources) == 0 {
+			return errors.New("CleanSources requires at least one source")
+		}
+		cmdArgs = append(cmdArgs, "--plugin-clean-sources")
+	}
+	for _, source := range opts.Sources {
+		if u, err := url.Parse(source); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid plugin source %q", source)
+		}
+		cmdArgs = append(cmdArgs, "--plugin-source", source)
+	}
 
 	w.logger.Infof("Installing vagrant plugin: %s", plugin.Name)
-	return w.execLogOutput(cmdArgs...)
+	return w.execLogOutput(ctx, cmdArgs...)
+}
+
+// PluginUninstall removes the plugin with the given name.
+func (w wrapper) PluginUninstall(name string) error {
+	return w.PluginUninstallContext(context.Background(), name)
+}
+
+// PluginUninstallContext is like PluginUninstall but includes a context.
+func (w wrapper) PluginUninstallContext(ctx context.Context, name string) error {
+	if len(name) == 0 {
+		return errors.New("plugin must have a name")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid plugin name %q", name)
+	}
+
+	w.logger.Infof("Uninstalling vagrant plugin: %s", name)
+	return w.execLogOutput(ctx, "plugin", "uninstall", name)
+}
+
+// PluginUpdate updates the plugin with the given name to the latest version. All installed plugins are updated when
+// the name is empty.
+func (w wrapper) PluginUpdate(name string) error {
+	return w.PluginUpdateContext(context.Background(), name)
+}
+
+// PluginUpdateContext is like PluginUpdate but includes a context.
+func (w wrapper) PluginUpdateContext(ctx context.Context, name string) error {
+	cmdArgs := []string{"plugin", "update"}
+
+	if len(name) > 0 {
+		if strings.HasPrefix(name, "-") {
+			return fmt.Errorf("invalid plugin name %q", name)
+		}
+		cmdArgs = append(cmdArgs, name)
+		w.logger.Infof("Updating vagrant plugin: %s", name)
+	} else {
+		w.logger.Infof("Updating all vagrant plugins")
+	}
+	return w.execLogOutput(ctx, cmdArgs...)
+}
+
+// PluginRepair attempts to repair installed plugins, e.g. after a Ruby upgrade has corrupted them.
+func (w wrapper) PluginRepair() error {
+	return w.PluginRepairContext(context.Background())
+}
+
+// PluginRepairContext is like PluginRepair but includes a context.
+func (w wrapper) PluginRepairContext(ctx context.Context) error {
+	w.logger.Infof("Repairing vagrant plugins")
+	return w.execLogOutput(ctx, "plugin", "repair")
+}
+
+// PluginExpunge removes all user installed plugins and their data. When reinstall is true the plugins are installed
+// again afterwards. The confirmation prompt is always skipped so the command cannot hang.
+func (w wrapper) PluginExpunge(reinstall bool) error {
+	return w.PluginExpungeContext(context.Background(), reinstall)
+}
+
+// PluginExpungeContext is like PluginExpunge but includes a context.
+func (w wrapper) PluginExpungeContext(ctx context.Context, reinstall bool) error {
+	cmdArgs := []string{"plugin", "expunge"}
+
+	if reinstall {
+		cmdArgs = append(cmdArgs, "--reinstall")
+	}
+	cmdArgs = append(cmdArgs, "--force")
+
+	w.logger.Infof("Expunging vagrant plugins")
+	return w.execLogOutput(ctx, cmdArgs...)
 }
 
 // IsPluginInstalled checks if a plugin has already been installed. It will return an error if the plugin arg has no
 // name or the underlying list operation fails.
-func (w wrapper) IsPluginInstalled(plugin Plugin) (installed bool, err error) {
+func (w wrapper) IsPluginInstalled(plugin Plugin) (bool, error) {
+	return w.IsPluginInstalledContext(context.Background(), plugin)
+}
+
+// IsPluginInstalledContext is like IsPluginInstalled but includes a context.
+func (w wrapper) IsPluginInstalledContext(ctx context.Context, plugin Plugin) (installed bool, err error) {
 	if len(plugin.Name) == 0 {
 		err = errors.New("plugin must have a Name")
 		return
 	}
 
-	installedPlugins, err := w.PluginList()
+	installedPlugins, err := w.PluginListContext(ctx)
 	if err != nil {
 		return
 	}
@@ -225,22 +971,340 @@ func (w wrapper) IsPluginInstalled(plugin Plugin) (installed bool, err error) {
 	return
 }
 
+// IsCreated checks if any machine in the environment has been created, i.e. is in a state other than NotCreated.
+// When machine names are given only those machines are considered, and an error is returned if vagrant does not
+// report one of them.
+func (w wrapper) IsCreated(machines ...string) (bool, error) {
+	return w.IsCreatedContext(context.Background(), machines...)
+}
+
+// IsCreatedContext is like IsCreated but includes a context.
+func (w wrapper) IsCreatedContext(ctx context.Context, machines ...string) (created bool, err error) {
+	statuses, err := w.StatusContext(ctx)
+	if err != nil {
+		return
+	}
+
+	states := map[string]MachineState{}
+	for _, status := range statuses {
+		states[status.Name] = status.State
+	}
+	if len(machines) == 0 {
+		for _, status := range statuses {
+			machines = append(machines, status.Name)
+		}
+	}
+
+	for _, name := range machines {
+		state, ok := states[name]
+		if !ok {
+			return false, fmt.Errorf("machine %s not found", name)
+		}
+		if state != NotCreated {
+			created = true
+		}
+	}
+	return
+}
+
+// CheckInstall verifies that the vagrant executable exists and is executable without running it, so that callers can
+// fail fast at startup with a clear message. A *command.BinaryNotFoundError is returned otherwise.
+func (w wrapper) CheckInstall() error {
+	if _, err := exec.LookPath(w.executable); err != nil {
+		return &command.BinaryNotFoundError{Name: w.executable, Err: err}
+	}
+	return nil
+}
+
+// environ converts the configured environment variables into sorted "key=value" pairs.
+func (w wrapper) environ() []string {
+	var env []string
+	for k, v := range w.env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// validateMachineNames ensures machine names are non-empty, free of shell metacharacters and cannot be mistaken for
+// flags.
+func validateMachineNames(names []string) error {
+	for _, name := range names {
+		if len(name) == 0 {
+			return errors.New("machine name cannot be empty")
+		}
+		if strings.HasPrefix(name, "-") {
+			return fmt.Errorf("machine name %q cannot start with a dash", name)
+		}
+		if strings.ContainsAny(name, shellMetachars) {
+			return fmt.Errorf("machine name %q contains invalid characters", name)
+		}
+	}
+	return nil
+}
+
+// appendExtraArgs validates the extra arguments of an options struct and appends them to cmdArgs. Every argument must
+// be a flag, with any value attached as in --flag=value, so that extra arguments cannot be taken for a subcommand or a
+// machine name. "--" is rejected because it ends flag parsing.
+func appendExtraArgs(cmdArgs, extraArgs []string) ([]string, error) {
+	for _, arg := range extraArgs {
+		switch {
+		case len(arg) == 0:
+			return nil, errors.New("extra argument cannot be empty")
+		case arg == "--":
+			return nil, errors.New(`extra arguments cannot contain "--"`)
+		case !strings.HasPrefix(arg, "-"):
+			return nil, fmt.Errorf("extra argument %q must be a flag, use --flag=value to pass a value", arg)
+		}
+	}
+	return append(cmdArgs, extraArgs...), nil
+}
+
+// boolFlag returns --name or --no-name depending on the value.
+func boolFlag(name string, value bool) string {
+	if value {
+		return "--" + name
+	}
+	return "--no-" + name
+}
+
+// checkVagrantfile verifies that the Vagrantfile configured with WithVagrantfile, or else one for the working
+// directory, exists.
+func (w wrapper) checkVagrantfile() error {
+	if len(w.vagrantfile) > 0 {
+		info, err := os.Stat(w.vagrantfile)
+		if err != nil {
+			return fmt.Errorf("invalid Vagrantfile: %w", err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("invalid Vagrantfile: %s is a directory", w.vagrantfile)
+		}
+		return nil
+	}
+	if len(w.dir) > 0 {
+		return checkVagrantfile(w.dir)
+	}
+	return nil
+}
+
+// checkVagrantfile verifies that dir exists and that a Vagrantfile can be found in it or one of its parents, which
+// mirrors how vagrant itself locates the Vagrantfile.
+func checkVagrantfile(dir string) error {
+	_, err := findVagrantfileDir(dir)
+	return err
+}
+
+// findVagrantfileDir returns the absolute path of dir or its closest parent that contains a Vagrantfile.
+func findVagrantfileDir(dir string) (string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid working directory: %s is not a directory", dir)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := absDir; ; d = filepath.Dir(d) {
+		for _, name := range []string{"Vagrantfile", "vagrantfile"} {
+			if fi, err := os.Stat(filepath.Join(d, name)); err == nil && !fi.IsDir() {
+				return d, nil
+			}
+		}
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	return "", fmt.Errorf("no Vagrantfile found in %s or its parent directories", absDir)
+}
+
+// vagrantfileDir returns the absolute path of the directory containing the Vagrantfile of the environment, which is
+// the directory of the Vagrantfile set with WithVagrantfile or the closest directory with a Vagrantfile otherwise.
+func (w wrapper) vagrantfileDir() (string, error) {
+	if len(w.vagrantfile) > 0 {
+		return filepath.Abs(filepath.Dir(w.vagrantfile))
+	}
+	return findVagrantfileDir(w.dir)
+}
+
 // exec dispatches vagrant commands via the shell runner.
-func (w wrapper) exec(args ...string) ([]byte, error) {
-	fullCmd := fmt.Sprintf("%s %s", w.executable, strings.Join(args, " "))
+func (w wrapper) exec(ctx context.Context, args ...string) ([]byte, error) {
+	out, _, err := w.execExitCode(ctx, args...)
+	return out, err
+}
 
-	w.logger.Debugf("Running command [%s]", fullCmd)
-	bs, err := w.runner.Execute(w.executable, args...)
-	w.logger.Debugf("Command output [%s]: %s", fullCmd, bs)
+// execTool runs a host tool other than vagrant, such as virsh or scp, with the same logging, hooks, timeouts and
+// serialization as vagrant commands. No Vagrantfile is required since the tool does not read it.
+func (w wrapper) execTool(ctx context.Context, tool string, args ...string) ([]byte, error) {
+	w.executable = tool
+	w.hostTool = true
+	return w.exec(ctx, args...)
+}
 
+// execExitCode is like exec but also returns the exit code of a command that exited with a code accepted with
+// WithAcceptedExitCodes, in which case no error is returned. The code is 0 in every other case.
+func (w wrapper) execExitCode(ctx context.Context, args ...string) ([]byte, int, error) {
+	if !globalCommands[args[0]] && !w.hostTool {
+		if err := w.checkVagrantfile(); err != nil {
+			return nil, 0, &Error{Kind: VagrantfileNotFound, Err: err}
+		}
+	}
+
+	if w.dryRun {
+		w.logger.Infof("Dry run, not running command [%s]", w.commandLine(args))
+		return nil, 0, nil
+	}
+
+	if w.serialize != nil && !longRunningCommands[args[0]] {
+		select {
+		case w.serialize <- struct{}{}:
+			defer func() { <-w.serialize }()
+		case <-ctx.Done():
+			return nil, 0, fmt.Errorf("%s interrupted: %w", w.executable, ctx.Err())
+		}
+	}
+
+	out, err := w.execTimeout(ctx, args...)
+	if code, ok := w.acceptedExitCode(args, err); ok {
+		w.logger.Debugf("Treating exit status %d of command [%s] as success", code, w.commandLine(args))
+		return out, code, nil
+	}
+	return out, 0, err
+}
+
+// acceptedExitCode reports whether err is an exit error with a code accepted for the command, either for all commands
+// or for a subcommand that args start with.
+func (w wrapper) acceptedExitCode(args []string, err error) (int, bool) {
+	var ee command.ExitError
+	if len(w.acceptedCodes) == 0 || !errors.As(err, &ee) {
+		return 0, false
+	}
+
+	cmdLine := strings.Join(args, " ") + " "
+	for subcommand, codes := range w.acceptedCodes {
+		if len(subcommand) > 0 && !strings.HasPrefix(cmdLine, subcommand+" ") {
+			continue
+		}
+		if slices.Contains(codes, ee.ExitCode()) {
+			return ee.ExitCode(), true
+		}
+	}
+	return 0, false
+}
+
+// execOnce runs a single vagrant command and classifies any error it produces.
+func (w wrapper) execOnce(ctx context.Context, args ...string) ([]byte, error) {
+	fullCmd := w.commandLine(args)
+
+	if !w.structured {
+		w.logger.Debugf("Running command [%s]", fullCmd)
+	}
+	var bs []byte
+	var err error
+	w.observeStart(args)
+	start := time.Now()
+	switch {
+	case w.stdout != nil || w.stderr != nil:
+		bs, err = w.execStream(ctx, args...)
+	case w.combined:
+		bs, err = command.CombinedOutput(ctx, w.runner, w.executable, args...)
+	default:
+		bs, err = w.runner.ExecuteContext(ctx, w.executable, args...)
+	}
+	elapsed := time.Since(start)
+	if w.structured {
+		w.logCommand(args, elapsed, bs, err)
+	} else {
+		w.logger.Debugf("Command output [%s]: %s", fullCmd, w.redactOutput(args, bs))
+	}
+
+	err = classifyError(err)
+	w.observeEnd(args, elapsed, err)
+	w.runHooks(args, elapsed, err)
 	return bs, err
 }
 
+// logCommand logs a completed command as a structured entry. The exit code is -1 when the command could not be run
+// or was killed.
+func (w wrapper) logCommand(args []string, elapsed time.Duration, out []byte, err error) {
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		var ee command.ExitError
+		if errors.As(err, &ee) {
+			exitCode = ee.ExitCode()
+		}
+	}
+
+	redacted, _ := w.redactArgs(args)
+	fields := log.Fields{
+		"command":      w.executable,
+		"args":         redacted,
+		"duration":     elapsed,
+		"exit_code":    exitCode,
+		"output_bytes": len(out),
+	}
+	if err != nil {
+		fields["error"] = string(w.redactOutput(args, []byte(err.Error())))
+	}
+	debugFields(w.logger, "Command executed", fields)
+}
+
+// execStream copies command output to the configured writers while also capturing standard output, or both streams
+// when combined output is enabled. Nothing is captured for long-running commands whose output is never returned, so
+// that their memory use does not grow for as long as they run.
+func (w wrapper) execStream(ctx context.Context, args ...string) ([]byte, error) {
+	if w.discardOutput {
+		return nil, w.runner.ExecuteStream(ctx, w.stdout, w.stderr, w.executable, args...)
+	}
+	if w.combined {
+		return command.CombinedOutputStream(ctx, w.runner, w.stdout, w.stderr, w.executable, args...)
+	}
+
+	var buf bytes.Buffer
+	stdout := io.Writer(&buf)
+	if w.stdout != nil {
+		stdout = io.MultiWriter(&buf, w.stdout)
+	}
+
+	err := w.runner.ExecuteStream(ctx, stdout, w.stderr, w.executable, args...)
+	return buf.Bytes(), err
+}
+
 // execLogOutput logs the output of the command at an info level instead of returning it.
-func (w wrapper) execLogOutput(args ...string) error {
-	out, err := w.exec(args...)
-	if len(out) > 0 {
-		w.logger.Info(string(out))
+func (w wrapper) execLogOutput(ctx context.Context, args ...string) error {
+	out, err := w.exec(ctx, args...)
+	w.logOutput(w.redactOutput(args, out))
+	return err
+}
+
+// execUntilCancelled runs a long-running command, logging its output line by line as it is produced unless it is
+// streamed to the caller. The command is killed when the context is done or the wrapper is closed, in which case nil
+// is returned and the output written from then on, including a partial last line, is not logged.
+func (w wrapper) execUntilCancelled(ctx context.Context, args ...string) error {
+	ctx, done := w.procs.track(ctx)
+	defer done()
+
+	if w.stdout == nil {
+		lw := &lineWriter{done: ctx.Done(), onLine: func(line string) { w.logger.Infof("%s", line) }}
+		defer lw.Flush()
+		w.stdout = lw
+	}
+	w.discardOutput = true
+
+	_, err := w.exec(ctx, args...)
+	if err != nil && ctx.Err() != nil {
+		return nil
 	}
 	return err
 }
+
+// logOutput logs command output at an info level unless it has already been streamed to the caller.
+func (w wrapper) logOutput(out []byte) {
+	if len(out) > 0 && w.stdout == nil {
+		w.logger.Infof("%s", out)
+	}
+}
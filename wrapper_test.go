@@ -1,9 +1,14 @@
 package vagrantexec
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/dominodatalab/vagrant-exec/command"
 	"github.com/sirupsen/logrus"
@@ -24,12 +29,28 @@ func (m *mockRunner) Execute(cmd string, cmdargs ...string) ([]byte, error) {
 	return nil, args.Error(1)
 }
 
+func (m *mockRunner) ExecuteContext(ctx context.Context, cmd string, cmdargs ...string) ([]byte, error) {
+	args := m.Called(ctx, cmd, cmdargs)
+	if output, ok := args.Get(0).([]byte); ok {
+		return output, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockRunner) ExecuteStream(ctx context.Context, stdout, stderr io.Writer, cmd string, cmdargs ...string) error {
+	args := m.Called(ctx, stdout, stderr, cmd, cmdargs)
+	if output, ok := args.Get(0).([]byte); ok && stdout != nil {
+		stdout.Write(output)
+	}
+	return args.Error(1)
+}
+
 // mockedWrapperFn returns a generator func that creates a wrapper with a mocked runnner. The func expects the output
 // and error values that the runner will return when invoked.
 func mockedWrapperFn(runnerArgs []string) func([]byte, error) wrapper {
 	return func(out []byte, err error) wrapper {
 		runner := new(mockRunner)
-		runner.On("Execute", "vagrant", runnerArgs).Return(out, err)
+		runner.On("ExecuteContext", mock.Anything, "vagrant", runnerArgs).Return(out, err)
 
 		logger := logrus.New()
 		logger.Out = ioutil.Discard
@@ -81,6 +102,159 @@ func TestUp(t *testing.T) {
 		w := mockUp(nil, errors.New("up failed"))
 		assert.Error(t, w.Up())
 	})
+
+	t.Run("machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up", "web", "db"})(nil, nil)
+		assert.NoError(t, w.Up("web", "db"))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockUp(nil, nil)
+
+		err := w.Up("web; rm -rf /")
+		require.Error(t, err)
+		assert.Equal(t, `machine name "web; rm -rf /" contains invalid characters`, err.Error())
+	})
+}
+
+func TestUpWithOptions(t *testing.T) {
+	enabled, disabled := true, false
+
+	t.Run("defaults", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		assert.NoError(t, w.UpWithOptions(UpOptions{}))
+	})
+
+	t.Run("all_options", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up", "--provider", "docker", "--parallel", "--no-provision", "web"})(nil, nil)
+		assert.NoError(t, w.UpWithOptions(UpOptions{
+			Provider:  "docker",
+			Parallel:  &enabled,
+			Provision: &disabled,
+			Machines:  []string{"web"},
+		}))
+	})
+
+	t.Run("no_parallel", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up", "--no-parallel", "--provision"})(nil, nil)
+		assert.NoError(t, w.UpWithOptions(UpOptions{Parallel: &disabled, Provision: &enabled}))
+	})
+
+	t.Run("destroy_on_error", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up", "--no-destroy-on-error", "web"})(nil, nil)
+		assert.NoError(t, w.UpWithOptions(UpOptions{DestroyOnError: &disabled, Machines: []string{"web"}}))
+
+		w = mockedWrapperFn([]string{"up", "--destroy-on-error"})(nil, nil)
+		assert.NoError(t, w.UpWithOptions(UpOptions{DestroyOnError: &enabled}))
+	})
+
+	t.Run("invalid_provider", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+
+		err := w.UpWithOptions(UpOptions{Provider: "virtualbox --debug"})
+		require.Error(t, err)
+		assert.Equal(t, `invalid provider "virtualbox --debug"`, err.Error())
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+		assert.Error(t, w.UpWithOptions(UpOptions{Machines: []string{""}}))
+	})
+
+	t.Run("extra_args", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up", "--provider", "libvirt", "--debug", "--install-provider", "web"})(nil, nil)
+		assert.NoError(t, w.UpWithOptions(UpOptions{
+			Provider:  "libvirt",
+			ExtraArgs: []string{"--debug", "--install-provider"},
+			Machines:  []string{"web"},
+		}))
+	})
+
+	t.Run("extra_args_subcommand", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"up"})(nil, nil)
+
+		err := w.UpWithOptions(UpOptions{ExtraArgs: []string{"destroy"}})
+		require.Error(t, err)
+		assert.Equal(t, `extra argument "destroy" must be a flag, use --flag=value to pass a value`, err.Error())
+	})
+}
+
+func TestUpBoxProgress(t *testing.T) {
+	type progress struct {
+		box string
+		pct int
+	}
+	upArgs := []string{"up", "--machine-readable", "--no-color"}
+
+	t.Run("download", func(t *testing.T) {
+		out, err := ioutil.ReadFile("testdata/up-box-progress")
+		require.NoError(t, err)
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: out}, "vagrant", append(upArgs, "default")...)
+		logger := &recordingLogger{}
+		w := wrapper{executable: binary, runner: runner, logger: logger}
+
+		var reported []progress
+		require.NoError(t, w.UpWithOptions(UpOptions{
+			Machines:      []string{"default"},
+			OnBoxProgress: func(box string, pct int) { reported = append(reported, progress{box, pct}) },
+		}))
+		assert.Equal(t, []progress{
+			{"ubuntu/focal64", 0},
+			{"ubuntu/focal64", 57},
+			{"ubuntu/focal64", 98},
+			{"ubuntu/focal64", 100},
+		}, reported)
+		assert.Contains(t, logger.lines, "INFO ==> default: Machine booted and ready!")
+	})
+
+	t.Run("box_present", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Output: []byte("1602771220,default,ui,info,==> default: Importing base box 'ubuntu/focal64'...\n")},
+			"vagrant", upArgs...)
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		called := false
+		require.NoError(t, w.UpWithOptions(UpOptions{OnBoxProgress: func(string, int) { called = true }}))
+		assert.False(t, called)
+	})
+}
+
+func TestUpWithResult(t *testing.T) {
+	mockUp := mockedWrapperFn([]string{"up", "--machine-readable", "--no-color"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockUp(ioutil.ReadFile("testdata/up-result"))
+
+		result, err := w.UpWithResult(UpOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, &UpResult{Booted: []string{"db"}, Unchanged: []string{"web"}}, result)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		provision := false
+		w := mockedWrapperFn([]string{"up", "--machine-readable", "--no-color", "--provider", "docker", "--no-provision", "db"})(nil, nil)
+
+		result, err := w.UpWithResult(UpOptions{Provider: "docker", Provision: &provision, Machines: []string{"db"}})
+		require.NoError(t, err)
+		assert.Empty(t, result.Booted)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		out, _ := ioutil.ReadFile("testdata/up-result")
+		w := mockUp(out, errors.New("runner error"))
+
+		result, err := w.UpWithResult(UpOptions{})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"db"}, result.Booted)
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockUp(nil, nil)
+
+		_, err := w.UpWithResult(UpOptions{Machines: []string{"web;rm"}})
+		assert.Error(t, err)
+	})
 }
 
 func TestHalt(t *testing.T) {
@@ -95,6 +269,33 @@ func TestHalt(t *testing.T) {
 		w := mockHalt(nil, errors.New("halt failed"))
 		assert.Error(t, w.Halt())
 	})
+
+	t.Run("machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"halt", "web"})(nil, nil)
+		assert.NoError(t, w.Halt("web"))
+	})
+
+	t.Run("empty_machine", func(t *testing.T) {
+		w := mockHalt(nil, nil)
+		assert.EqualError(t, w.Halt(""), "machine name cannot be empty")
+	})
+}
+
+func TestHaltWithOptions(t *testing.T) {
+	t.Run("graceful", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"halt"})(nil, nil)
+		assert.NoError(t, w.HaltWithOptions(HaltOptions{}))
+	})
+
+	t.Run("force", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"halt", "--force", "web"})(nil, nil)
+		assert.NoError(t, w.HaltWithOptions(HaltOptions{Force: true, Machines: []string{"web"}}))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"halt"})(nil, nil)
+		assert.Error(t, w.HaltWithOptions(HaltOptions{Machines: []string{"web|db"}}))
+	})
 }
 
 func TestDestroy(t *testing.T) {
@@ -109,10 +310,213 @@ func TestDestroy(t *testing.T) {
 		w := mockDestroy(nil, errors.New("destroy failed"))
 		assert.Error(t, w.Destroy())
 	})
+
+	t.Run("machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"destroy", "--force", "web", "db"})(nil, nil)
+		assert.NoError(t, w.Destroy("web", "db"))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockDestroy(nil, nil)
+		assert.Error(t, w.Destroy("$(whoami)"))
+	})
+}
+
+func TestDestroyWithOptions(t *testing.T) {
+	enabled := true
+
+	t.Run("all_machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"destroy", "--force"})(nil, nil)
+		assert.NoError(t, w.DestroyWithOptions(DestroyOptions{}))
+	})
+
+	t.Run("machines_in_parallel", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"destroy", "--force", "--parallel", "web", "worker"})(nil, nil)
+		assert.NoError(t, w.DestroyWithOptions(DestroyOptions{Parallel: &enabled, Machines: []string{"web", "worker"}}))
+	})
+
+	t.Run("graceful", func(t *testing.T) {
+		// --force is always kept so that a graceful destroy still does not prompt
+		w := mockedWrapperFn([]string{"destroy", "--force", "--graceful", "db"})(nil, nil)
+		assert.NoError(t, w.DestroyWithOptions(DestroyOptions{Graceful: true, Machines: []string{"db"}}))
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		require.NoError(t, w.DestroyWithOptions(DestroyOptions{Prune: true, Machines: []string{"db"}}))
+		calls := runner.Calls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "vagrant destroy --force db", calls[0].String())
+		assert.Equal(t, "vagrant global-status --no-color --prune", calls[1].String())
+	})
+
+	t.Run("prune_skipped_on_error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("destroy failed")}, "vagrant", "destroy", "--force")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		assert.EqualError(t, w.DestroyWithOptions(DestroyOptions{Prune: true}), "destroy failed")
+		assert.Len(t, runner.Calls(), 1)
+	})
+
+	t.Run("prune_error", func(t *testing.T) {
+		runner := &command.MockRunner{}
+		runner.AddResponse(command.Response{Err: errors.New("index locked")}, "vagrant", "global-status", "--no-color", "--prune")
+		w := wrapper{executable: binary, runner: runner, logger: &recordingLogger{}}
+
+		err := w.DestroyWithOptions(DestroyOptions{Prune: true})
+		assert.EqualError(t, err, "machines destroyed but the machine index could not be pruned: index locked")
+	})
+
+	t.Run("graceful_in_parallel", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"destroy", "--force", "--graceful", "--parallel"})(nil, nil)
+		assert.NoError(t, w.DestroyWithOptions(DestroyOptions{Graceful: true, Parallel: &enabled}))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"destroy", "--force"})(nil, nil)
+		assert.Error(t, w.DestroyWithOptions(DestroyOptions{Machines: []string{"db*"}}))
+	})
+
+	t.Run("extra_args", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"destroy", "--force", "--graceful", "--timestamp", "db"})(nil, nil)
+		assert.NoError(t, w.DestroyWithOptions(DestroyOptions{
+			Graceful:  true,
+			ExtraArgs: []string{"--timestamp"},
+			Machines:  []string{"db"},
+		}))
+	})
+}
+
+func TestAppendExtraArgs(t *testing.T) {
+	testcases := []struct {
+		name      string
+		extraArgs []string
+		expected  []string
+		err       string
+	}{
+		{name: "none", expected: []string{"up"}},
+		{name: "flags", extraArgs: []string{"--debug", "--timestamp"}, expected: []string{"up", "--debug", "--timestamp"}},
+		{name: "flag_equals", extraArgs: []string{"--color=always"}, expected: []string{"up", "--color=always"}},
+		{name: "subcommand", extraArgs: []string{"halt"}, err: `extra argument "halt" must be a flag, use --flag=value to pass a value`},
+		{name: "machine_after_flag", extraArgs: []string{"--debug", "web"}, err: `extra argument "web" must be a flag, use --flag=value to pass a value`},
+		{name: "end_of_flags", extraArgs: []string{"--"}, err: `extra arguments cannot contain "--"`},
+		{name: "empty", extraArgs: []string{""}, err: "extra argument cannot be empty"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := appendExtraArgs([]string{"up"}, tc.extraArgs)
+			if len(tc.err) > 0 {
+				require.Error(t, err)
+				assert.Equal(t, tc.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	mockSuspend := mockedWrapperFn([]string{"suspend"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockSuspend([]byte("suspend output"), nil)
+		assert.NoError(t, w.Suspend())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockSuspend(nil, errors.New("suspend failed"))
+		assert.Error(t, w.Suspend())
+	})
+
+	t.Run("machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"suspend", "web"})(nil, nil)
+		assert.NoError(t, w.Suspend("web"))
+	})
+}
+
+func TestResume(t *testing.T) {
+	mockResume := mockedWrapperFn([]string{"resume"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockResume([]byte("resume output"), nil)
+		assert.NoError(t, w.Resume())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockResume(nil, errors.New("resume failed"))
+		assert.Error(t, w.Resume())
+	})
+
+	t.Run("machines", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"resume", "web"})(nil, nil)
+		assert.NoError(t, w.Resume("web"))
+	})
+}
+
+func TestReload(t *testing.T) {
+	mockReload := mockedWrapperFn([]string{"reload"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockReload([]byte("reload output"), nil)
+		assert.NoError(t, w.Reload(ReloadOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockReload(nil, errors.New("reload failed"))
+		assert.Error(t, w.Reload(ReloadOptions{}))
+	})
+
+	t.Run("with_options", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"reload", "--provision", "web"})(nil, nil)
+		assert.NoError(t, w.Reload(ReloadOptions{Provision: true, Machines: []string{"web"}}))
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockReload(nil, nil)
+		assert.Error(t, w.Reload(ReloadOptions{Machines: []string{"web|db"}}))
+	})
+}
+
+func TestProvision(t *testing.T) {
+	mockProvision := mockedWrapperFn([]string{"provision"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockProvision([]byte("provision output"), nil)
+		assert.NoError(t, w.Provision(ProvisionOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockProvision(nil, errors.New("provision failed"))
+		assert.Error(t, w.Provision(ProvisionOptions{}))
+	})
+
+	t.Run("provision_with", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"provision", "--provision-with", "shell,file", "web"})(nil, nil)
+
+		opts := ProvisionOptions{
+			ProvisionWith: []string{"shell", "file"},
+			Machines:      []string{"web"},
+		}
+		assert.NoError(t, w.Provision(opts))
+	})
+
+	t.Run("invalid_provisioner", func(t *testing.T) {
+		w := mockProvision(nil, nil)
+
+		for _, provisioners := range [][]string{{""}, {"shell", " "}, {"shell,file"}} {
+			err := w.Provision(ProvisionOptions{ProvisionWith: provisioners})
+			assert.Error(t, err, "expected error for %q", provisioners)
+		}
+	})
 }
 
 func TestStatus(t *testing.T) {
-	mockStatus := mockedWrapperFn([]string{"status", "--machine-readable"})
+	mockStatus := mockedWrapperFn([]string{"status", "--machine-readable", "--no-color"})
 
 	t.Run("one_machine", func(t *testing.T) {
 		w := mockStatus(ioutil.ReadFile("testdata/status-single"))
@@ -122,9 +526,10 @@ func TestStatus(t *testing.T) {
 
 		expected := []MachineStatus{
 			{
-				Name:     "srv-1",
-				Provider: "virtualbox",
-				State:    NotCreated,
+				Name:        "srv-1",
+				Provider:    "virtualbox",
+				State:       NotCreated,
+				LastUpdated: time.Unix(1562176079, 0),
 			},
 		}
 		assert.EqualValues(t, expected, statuses)
@@ -138,19 +543,141 @@ func TestStatus(t *testing.T) {
 
 		expected := []MachineStatus{
 			{
-				Name:     "srv-1",
-				Provider: "virtualbox",
-				State:    Running,
+				Name:        "srv-1",
+				Provider:    "virtualbox",
+				State:       Running,
+				LastUpdated: time.Unix(1562175814, 0),
 			},
 			{
-				Name:     "srv-2",
-				Provider: "virtualbox",
-				State:    PowerOff,
+				Name:        "srv-2",
+				Provider:    "virtualbox",
+				State:       PowerOff,
+				LastUpdated: time.Unix(1562175814, 0),
 			},
 		}
 		assert.ElementsMatch(t, expected, statuses)
 	})
 
+	t.Run("suspended_machine", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-saved"))
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+		assert.Equal(t, Saved, statuses[0].State)
+	})
+
+	t.Run("color_output", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-color"))
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+		assert.Equal(t, []MachineStatus{{
+			Name:        "srv-1",
+			Provider:    "virtualbox",
+			State:       Running,
+			LastUpdated: time.Unix(1562175814, 0),
+		}}, statuses)
+	})
+
+	t.Run("machine_ids", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-ids"))
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.Equal(t, "a1b2c3d", statuses[0].ID)
+		assert.Empty(t, statuses[1].ID)
+		assert.Equal(t, NotCreated, statuses[1].State)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-partial"))
+
+		statuses, err := w.Status()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid machine-readable format: [1562175814 srv-2 state]")
+		assert.Contains(t, err.Error(), "no state reported for machine srv-2")
+
+		require.Len(t, statuses, 2)
+		assert.Equal(t, "srv-1", statuses[0].Name)
+		assert.Equal(t, Running, statuses[0].State)
+		assert.Equal(t, "srv-3", statuses[1].Name)
+		assert.Equal(t, PowerOff, statuses[1].State)
+	})
+
+	t.Run("vagrant_versions", func(t *testing.T) {
+		testcases := []struct {
+			fixture   string
+			timestamp int64
+		}{
+			{"testdata/status-vagrant-2.2", 1562175814},
+			{"testdata/status-vagrant-2.3", 1669045363},
+		}
+		for _, tc := range testcases {
+			w := mockStatus(ioutil.ReadFile(tc.fixture))
+
+			statuses, err := w.Status()
+			require.NoError(t, err, tc.fixture)
+			assert.Equal(t, []MachineStatus{
+				{Name: "web", Provider: "virtualbox", State: Running, LastUpdated: time.Unix(tc.timestamp, 0)},
+				{Name: "db", Provider: "virtualbox", State: Saved, LastUpdated: time.Unix(tc.timestamp, 0)},
+			}, statuses, tc.fixture)
+		}
+	})
+
+	t.Run("field_count_variations", func(t *testing.T) {
+		out := "1562175814,web,metadata,provider,libvirt\n\n1562175814,web,state,running,extra\n" +
+			"1562175814,web,metadata,provider\n1562175814,db,metadata,provider,docker\n1562175814,db,provider-name,virtualbox\n" +
+			"1562175814,db,state,poweroff\n"
+		w := mockStatus([]byte(out), nil)
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.Equal(t, "libvirt", statuses[0].Provider)
+		assert.Equal(t, Running, statuses[0].State)
+		assert.Equal(t, "virtualbox", statuses[1].Provider)
+	})
+
+	t.Run("provider_unreachable", func(t *testing.T) {
+		stderr := "Error while connecting to Libvirt: Error making a connection to libvirt URI qemu:///system:\n" +
+			"Call to virConnectOpen failed: Failed to connect socket to '/var/run/libvirt/libvirt-sock': No such file or directory"
+		w := mockStatus(nil, command.NewExitError("vagrant", 1, stderr))
+
+		statuses, err := w.Status()
+		assert.Empty(t, statuses)
+		assert.True(t, IsProviderUnreachable(err))
+		assert.False(t, IsTransient(err))
+	})
+
+	t.Run("error_exit", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-error-exit"))
+
+		statuses, err := w.Status()
+		assert.Empty(t, statuses)
+
+		var mrErr *MachineReadableError
+		require.True(t, errors.As(err, &mrErr))
+		assert.Equal(t, "Vagrant::Errors::VagrantfileSyntaxError", mrErr.Class)
+		assert.Equal(t, "There is a syntax error in the following Vagrantfile. The syntax error\nmessage is reproduced "+
+			"below for convenience:\n\n/home/user/project/Vagrantfile:5: syntax error, unexpected end-of-input", mrErr.Message)
+		assert.Nil(t, mrErr.Err)
+	})
+
+	t.Run("error_exit_with_status", func(t *testing.T) {
+		out, _ := ioutil.ReadFile("testdata/status-error-exit")
+		exitErr := command.NewExitError("vagrant", 1, "")
+		w := mockStatus(out, exitErr)
+
+		_, err := w.Status()
+
+		var mrErr *MachineReadableError
+		require.True(t, errors.As(err, &mrErr))
+		assert.Equal(t, "Vagrant::Errors::VagrantfileSyntaxError", mrErr.Class)
+		assert.True(t, errors.Is(err, exitErr))
+	})
+
 	t.Run("error", func(t *testing.T) {
 		w := mockStatus(nil, errors.New("runner error"))
 
@@ -159,8 +686,30 @@ func TestStatus(t *testing.T) {
 	})
 }
 
+func TestStatusCRLF(t *testing.T) {
+	out, err := ioutil.ReadFile("testdata/status-multiple")
+	require.NoError(t, err)
+	w := mockedWrapperFn([]string{"status", "--machine-readable", "--no-color"})(bytes.ReplaceAll(out, []byte("\n"), []byte("\r\n")), nil)
+
+	statuses, err := w.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, MachineStatus{Name: "srv-1", Provider: "virtualbox", State: Running, LastUpdated: time.Unix(1562175814, 0)}, statuses[0])
+	assert.Equal(t, "virtualbox", statuses[1].Provider)
+	assert.Equal(t, PowerOff, statuses[1].State)
+}
+
+func TestStatusByState(t *testing.T) {
+	w := mockedWrapperFn([]string{"status", "--machine-readable", "--no-color"})(ioutil.ReadFile("testdata/status-multiple"))
+
+	statuses, err := w.StatusByState(PowerOff)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "srv-2", statuses[0].Name)
+}
+
 func TestVersion(t *testing.T) {
-	mockVersion := mockedWrapperFn([]string{"version", "--machine-readable"})
+	mockVersion := mockedWrapperFn([]string{"version", "--machine-readable", "--no-color"})
 
 	t.Run("success", func(t *testing.T) {
 		w := mockVersion(ioutil.ReadFile("testdata/version"))
@@ -213,10 +762,45 @@ func TestSSH(t *testing.T) {
 		_, err := w.SSH("", sshCmd)
 		assert.Error(t, err)
 	})
+
+	t.Run("flag_like_name", func(t *testing.T) {
+		w := mockSSH(nil, nil)
+
+		_, err := w.SSH("--help", sshCmd)
+		require.Error(t, err)
+		assert.Equal(t, `machine name "--help" cannot start with a dash`, err.Error())
+	})
+}
+
+func TestSSHWithOptions(t *testing.T) {
+	sshCmd := "./install.sh"
+
+	t.Run("tty", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh", "--command", sshCmd, "web"})([]byte("\x1b[32mdone\x1b[0m\r\n"), nil)
+
+		output, err := w.SSHWithOptions(sshCmd, SSHOptions{TTY: true, Machine: "web"})
+		require.NoError(t, err)
+		assert.Equal(t, "\x1b[32mdone\x1b[0m\r\n", output)
+	})
+
+	t.Run("no_tty", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh", "--no-tty", "--command", sshCmd})([]byte("done"), nil)
+
+		output, err := w.SSHWithOptions(sshCmd, SSHOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "done", output)
+	})
+
+	t.Run("invalid_machine", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"ssh", "--no-tty", "--command", sshCmd})(nil, nil)
+
+		_, err := w.SSHWithOptions(sshCmd, SSHOptions{Machine: "web db"})
+		assert.Error(t, err)
+	})
 }
 
 func TestPluginList(t *testing.T) {
-	mockPluginList := mockedWrapperFn([]string{"plugin", "list", "--machine-readable"})
+	mockPluginList := mockedWrapperFn([]string{"plugin", "list", "--machine-readable", "--no-color"})
 
 	t.Run("with_plugins", func(t *testing.T) {
 		w := mockPluginList(ioutil.ReadFile("testdata/plugin-list"))
@@ -239,6 +823,40 @@ func TestPluginList(t *testing.T) {
 		assert.EqualValues(t, expected, actual)
 	})
 
+	t.Run("version_constraints_and_locations", func(t *testing.T) {
+		w := mockPluginList(ioutil.ReadFile("testdata/plugin-list-local"))
+
+		actual, err := w.PluginList()
+		require.NoError(t, err)
+
+		expected := []Plugin{
+			{
+				Name:     "vagrant-vbguest",
+				Version:  "0.28.0",
+				Location: "global",
+			},
+			{
+				Name:     "vagrant.hostsupdater",
+				Version:  "1.2.0.pre",
+				Location: "local",
+			},
+		}
+		assert.EqualValues(t, expected, actual)
+	})
+
+	t.Run("unmatched_ui_entries", func(t *testing.T) {
+		out := []byte("1562938270,,ui,info,Installed the plugin 'vagrant.foo'!\n" +
+			"1562938270,,ui,info\n" +
+			"1562938270,,ui,info,vagrant.foo (1.0.0%!(VAGRANT_COMMA) global)\n")
+		w := mockPluginList(out, nil)
+
+		var actual []Plugin
+		var err error
+		require.NotPanics(t, func() { actual, err = w.PluginList() })
+		require.NoError(t, err)
+		assert.Equal(t, []Plugin{{Name: "vagrant.foo", Version: "1.0.0", Location: "global"}}, actual)
+	})
+
 	t.Run("no_plugins", func(t *testing.T) {
 		w := mockPluginList(ioutil.ReadFile("testdata/plugin-list-none"))
 
@@ -297,8 +915,121 @@ func TestPluginInstall(t *testing.T) {
 	})
 }
 
+func TestPluginInstallWithOptions(t *testing.T) {
+	plugin := Plugin{Name: "my-plugin"}
+
+	t.Run("sources", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"plugin", "install", "my-plugin", "--plugin-clean-sources",
+			"--plugin-source", "https://gems.example.com/", "--plugin-source", "http://mirror.internal:8080/rubygems"})(nil, nil)
+
+		assert.NoError(t, w.PluginInstallWithOptions(plugin, PluginInstallOptions{
+			Sources:      []string{"https://gems.example.com/", "http://mirror.internal:8080/rubygems"},
+			CleanSources: true,
+		}))
+	})
+
+	t.Run("invalid_source", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"plugin", "install", "my-plugin"})(nil, nil)
+
+		for _, source := range []string{"gems.example.com", "ftp://gems.example.com", "https://", "--local"} {
+			err := w.PluginInstallWithOptions(plugin, PluginInstallOptions{Sources: []string{source}})
+			require.Error(t, err, source)
+			assert.Equal(t, fmt.Sprintf("invalid plugin source %q", source), err.Error())
+		}
+	})
+
+	t.Run("clean_sources_without_sources", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"plugin", "install", "my-plugin"})(nil, nil)
+		assert.Error(t, w.PluginInstallWithOptions(plugin, PluginInstallOptions{CleanSources: true}))
+	})
+}
+
+func TestPluginUninstall(t *testing.T) {
+	mockPluginUninstall := mockedWrapperFn([]string{"plugin", "uninstall", "my-plugin"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockPluginUninstall([]byte("uninstall output"), nil)
+		assert.NoError(t, w.PluginUninstall("my-plugin"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPluginUninstall(nil, errors.New("runner error"))
+		assert.Error(t, w.PluginUninstall("my-plugin"))
+	})
+
+	t.Run("no_name", func(t *testing.T) {
+		w := mockPluginUninstall(nil, nil)
+
+		err := w.PluginUninstall("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "plugin must have a name")
+	})
+
+	t.Run("invalid_name", func(t *testing.T) {
+		w := mockPluginUninstall(nil, nil)
+		assert.Error(t, w.PluginUninstall("--local"))
+	})
+}
+
+func TestPluginUpdate(t *testing.T) {
+	mockPluginUpdate := mockedWrapperFn([]string{"plugin", "update", "my-plugin"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockPluginUpdate([]byte("update output"), nil)
+		assert.NoError(t, w.PluginUpdate("my-plugin"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPluginUpdate(nil, errors.New("runner error"))
+		assert.Error(t, w.PluginUpdate("my-plugin"))
+	})
+
+	t.Run("all_plugins", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"plugin", "update"})(nil, nil)
+		assert.NoError(t, w.PluginUpdate(""))
+	})
+
+	t.Run("invalid_name", func(t *testing.T) {
+		w := mockPluginUpdate(nil, nil)
+		assert.Error(t, w.PluginUpdate("--local"))
+	})
+}
+
+func TestPluginRepair(t *testing.T) {
+	mockPluginRepair := mockedWrapperFn([]string{"plugin", "repair"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockPluginRepair([]byte("repair output"), nil)
+		assert.NoError(t, w.PluginRepair())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPluginRepair(nil, errors.New("runner error"))
+		assert.Error(t, w.PluginRepair())
+	})
+}
+
+func TestPluginExpunge(t *testing.T) {
+	mockPluginExpunge := mockedWrapperFn([]string{"plugin", "expunge", "--force"})
+
+	t.Run("success", func(t *testing.T) {
+		w := mockPluginExpunge([]byte("expunge output"), nil)
+		assert.NoError(t, w.PluginExpunge(false))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockPluginExpunge(nil, errors.New("runner error"))
+		assert.Error(t, w.PluginExpunge(false))
+	})
+
+	t.Run("reinstall", func(t *testing.T) {
+		w := mockedWrapperFn([]string{"plugin", "expunge", "--reinstall", "--force"})(nil, nil)
+		assert.NoError(t, w.PluginExpunge(true))
+	})
+}
+
 func TestIsPluginInstalled(t *testing.T) {
-	mockPluginList := mockedWrapperFn([]string{"plugin", "list", "--machine-readable"})
+	mockPluginList := mockedWrapperFn([]string{"plugin", "list", "--machine-readable", "--no-color"})
 	w := mockPluginList(ioutil.ReadFile("testdata/plugin-list"))
 
 	testcases := []struct {
@@ -348,3 +1079,98 @@ func TestIsPluginInstalled(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestCheckInstall(t *testing.T) {
+	t.Run("installed", func(t *testing.T) {
+		w := New(".", false, WithBinary("sh")).(wrapper)
+		assert.NoError(t, w.CheckInstall())
+	})
+
+	t.Run("absolute_path", func(t *testing.T) {
+		w := New(".", false, WithBinary("/bin/sh")).(wrapper)
+		assert.NoError(t, w.CheckInstall())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		w := New(".", false, WithBinary("vagrant-exec-missing")).(wrapper)
+
+		err := w.CheckInstall()
+
+		var notFound *command.BinaryNotFoundError
+		require.True(t, errors.As(err, &notFound))
+		assert.Equal(t, "vagrant-exec-missing", notFound.Name)
+		assert.Contains(t, err.Error(), "vagrant-exec-missing is not installed or not executable")
+	})
+
+	t.Run("not_executable", func(t *testing.T) {
+		w := New(".", false, WithBinary("testdata/version")).(wrapper)
+
+		var notFound *command.BinaryNotFoundError
+		assert.True(t, errors.As(w.CheckInstall(), &notFound))
+	})
+}
+
+func TestIsCreated(t *testing.T) {
+	mockStatus := mockedWrapperFn([]string{"status", "--machine-readable", "--no-color"})
+
+	t.Run("single_machine", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-single"))
+
+		created, err := w.IsCreated()
+		require.NoError(t, err)
+		assert.False(t, created)
+	})
+
+	t.Run("any_machine", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-ids"))
+
+		created, err := w.IsCreated()
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("named_machine", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-ids"))
+
+		statuses, err := w.Status()
+		require.NoError(t, err)
+
+		created, err := w.IsCreated(statuses[0].Name)
+		require.NoError(t, err)
+		assert.True(t, created)
+
+		created, err = w.IsCreated(statuses[1].Name)
+		require.NoError(t, err)
+		assert.False(t, created)
+	})
+
+	t.Run("unknown_machine", func(t *testing.T) {
+		w := mockStatus(ioutil.ReadFile("testdata/status-single"))
+
+		_, err := w.IsCreated("missing")
+		assert.EqualError(t, err, "machine missing not found")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := mockStatus(nil, errors.New("runner error"))
+
+		_, err := w.IsCreated()
+		assert.Error(t, err)
+	})
+}
+
+func TestContextVariants(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runner := new(mockRunner)
+	runner.On("ExecuteContext", ctx, "vagrant", []string{"up"}).Return(nil, nil)
+	w := wrapper{
+		executable: binary,
+		logger:     logrus.New(),
+		runner:     runner,
+	}
+
+	require.NoError(t, w.UpContext(ctx))
+	runner.AssertExpectations(t)
+}